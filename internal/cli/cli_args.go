@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 )
@@ -193,6 +196,217 @@ func (args *Args) BoolFlag(name string, shorthand string, value bool) (bool, err
 	return result, err
 }
 
+// Take the value of the flag at index [i], either from the flag itself (--name=value) or from the argument following it
+// (--name value).
+func (args *Args) takeValue(i int, name string, shorthand string) (string, error) {
+	flag := &(*args)[i]
+	if flag.suffix != "" {
+		return "", fmt.Errorf("Flag %s cannot have a suffix", sprintFlagName(name, shorthand))
+	}
+	return args.takeScopedValue(i, name, shorthand)
+}
+
+// Take the value of the flag at index [i] like takeValue, but accept a suffix.
+func (args *Args) takeScopedValue(i int, name string, shorthand string) (string, error) {
+	flag := &(*args)[i]
+	if flag.hasValue {
+		return flag.value, nil
+	}
+	if i+1 < len(*args) {
+		next := &(*args)[i+1]
+		if !next.isFlag && !next.wasUsed {
+			next.wasUsed = true
+			return next.value, nil
+		}
+	}
+	return "", fmt.Errorf("Flag %s requires a value", sprintFlagName(name, shorthand))
+}
+
+// Read a flag that takes a value. The return value will be [value] if the flag is not set.
+func (args *Args) StringFlag(name string, shorthand string, value string) (string, error) {
+	values, err := args.StringSliceFlag(name, shorthand)
+	if err == nil && len(values) > 1 {
+		err = fmt.Errorf("Flag %s can only be used once", sprintFlagName(name, shorthand))
+	}
+	if len(values) > 0 {
+		value = values[len(values)-1]
+	}
+	return value, err
+}
+
+// Read a flag that takes a value and can be specified multiple times.
+func (args *Args) StringSliceFlag(name string, shorthand string) ([]string, error) {
+	var (
+		values []string
+		errs   []error
+	)
+	for i := range *args {
+		arg := &(*args)[i]
+		if !arg.wasUsed && arg.isFlag && (arg.name == name || arg.name == shorthand) {
+			arg.wasUsed = true
+			value, err := args.takeValue(i, name, shorthand)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				values = append(values, value)
+			}
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// Read a flag that takes a value and can be scoped with a suffix, like --output:fr=dist/fr.js. The values are returned
+// by suffix, the flag without suffix having an empty one. Each suffix can only be used once.
+func (args *Args) ScopedStringFlag(name string, shorthand string) (map[string]string, error) {
+	var (
+		values = make(map[string]string)
+		errs   []error
+	)
+	for i := range *args {
+		arg := &(*args)[i]
+		if !arg.wasUsed && arg.isFlag && (arg.name == name || arg.name == shorthand) {
+			arg.wasUsed = true
+			value, err := args.takeScopedValue(i, name, shorthand)
+			if _, exists := values[arg.suffix]; exists {
+				err = fmt.Errorf("Flag %s can only be used once", sprintScopedFlag(name, shorthand, arg.suffix))
+			}
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				values[arg.suffix] = value
+			}
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// Read a boolean flag that can be scoped with a suffix, like --check:ui. The suffixes the flag is used with are mapped
+// to [value], the flag without suffix having an empty one.
+func (args *Args) ScopedBoolFlag(name string, shorthand string, value bool) (map[string]bool, error) {
+	var (
+		values = make(map[string]bool)
+		errs   []error
+	)
+	for i := range *args {
+		arg := &(*args)[i]
+		if !arg.wasUsed && arg.isFlag && (arg.name == name || arg.name == shorthand) {
+			arg.wasUsed = true
+			if arg.hasValue {
+				errs = append(errs, fmt.Errorf("Flag %s cannot have a value", sprintFlagName(name, shorthand)))
+			} else {
+				values[arg.suffix] = value
+			}
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// Print a flag with its suffix.
+func sprintScopedFlag(name string, shorthand string, suffix string) string {
+	if suffix == "" {
+		return sprintFlagName(name, shorthand)
+	}
+	return fmt.Sprintf("\"--%s:%s\"", name, suffix)
+}
+
+// Read a flag like StringFlag, and return wether it is set. Unlike with StringFlag, an empty value is an error.
+func (args *Args) nonEmptyFlag(name string, shorthand string) (string, bool, error) {
+	values, err := args.StringSliceFlag(name, shorthand)
+	if err == nil && len(values) > 1 {
+		err = fmt.Errorf("Flag %s can only be used once", sprintFlagName(name, shorthand))
+	}
+	if err != nil || len(values) == 0 {
+		return "", false, err
+	}
+	if values[0] == "" {
+		return "", false, fmt.Errorf("Flag %s expects a value", sprintFlagName(name, shorthand))
+	}
+	return values[0], true, nil
+}
+
+// Read a flag that takes an integer. The return value will be [value] if the flag is not set.
+func (args *Args) IntFlag(name string, shorthand string, value int) (int, error) {
+	text, set, err := args.nonEmptyFlag(name, shorthand)
+	if !set {
+		return value, err
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return value, fmt.Errorf("Flag %s expects an integer but got \"%s\"", sprintFlagName(name, shorthand), text)
+	}
+	return n, nil
+}
+
+// Read a flag that takes one of [choices]. The return value will be [value] if the flag is not set.
+func (args *Args) EnumFlag(name string, shorthand string, value string, choices ...string) (string, error) {
+	text, set, err := args.nonEmptyFlag(name, shorthand)
+	if !set {
+		return value, err
+	}
+	if !slices.Contains(choices, text) {
+		return value, fmt.Errorf("Flag %s expects %s but got \"%s\"", sprintFlagName(name, shorthand),
+			describeChoice(choices), text)
+	}
+	return text, nil
+}
+
+// List the values accepted by a flag, like "a, b or c".
+func describeChoice(choices []string) string {
+	if len(choices) < 2 {
+		return strings.Join(choices, "")
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + " or " + choices[len(choices)-1]
+}
+
+// Read the next positional argument, which is required. [name] is used in error messages. Like Rest, this should be
+// called after reading the flags, and the arguments left are reported by Done.
+func (args *Args) Positional(name string) (string, error) {
+	for i := range *args {
+		arg := &(*args)[i]
+		if !arg.wasUsed && !arg.isFlag {
+			arg.wasUsed = true
+			return arg.value, nil
+		}
+	}
+	return "", fmt.Errorf("Missing <%s> argument", name)
+}
+
+// Read all the remaining positional arguments and check that there are between [minimum] and [maximum] of them.
+// A negative maximum means there is no limit. [name] is used in error messages. Like Rest, this should be called after
+// reading the flags.
+func (args *Args) Positionals(name string, minimum int, maximum int) ([]string, error) {
+	values := args.Rest()
+	if len(values) >= minimum && (maximum < 0 || len(values) <= maximum) {
+		return values, nil
+	}
+
+	var expected string
+	switch {
+	case minimum == maximum:
+		expected = fmt.Sprintf("exactly %d", minimum)
+	case maximum < 0:
+		expected = fmt.Sprintf("at least %d", minimum)
+	case minimum == 0:
+		expected = fmt.Sprintf("at most %d", maximum)
+	default:
+		expected = fmt.Sprintf("between %d and %d", minimum, maximum)
+	}
+	return values, fmt.Errorf("Expected %s <%s> argument(s) but got %d", expected, name, len(values))
+}
+
+// Read all the remaining arguments. This should be called after reading the flags, because flags may take the argument
+// following them as their value.
+func (args *Args) Rest() (rest []string) {
+	for i := range *args {
+		arg := &(*args)[i]
+		if !arg.wasUsed && !arg.isFlag {
+			rest = append(rest, arg.value)
+			arg.wasUsed = true
+		}
+	}
+	return rest
+}
+
 // Read the next argument as a command.
 func (args *Args) Command() (cmd string) {
 	for i := range *args {
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The shells CompletionScript can generate a script for.
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// The completion scripts, where PROGRAM is replaced with the name of the program. The scripts call "PROGRAM
+// __complete <words>..." which prints the candidates computed by Complete, one per line, and shells fall back to file
+// names when there are none.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for PROGRAM, generated by 'PROGRAM completion bash'
+_PROGRAM_completion() {
+	local line="${COMP_LINE:0:COMP_POINT}" words candidate
+	read -ra words <<< "$line"
+	[[ "$line" == *" " ]] && words+=("")
+	# bash splits words on = and :, so the part of the word before them is removed from the candidates
+	local current="${words[${#words[@]}-1]}"
+	local prefix="${current%"${COMP_WORDS[COMP_CWORD]}"}"
+	COMPREPLY=()
+	while IFS= read -r candidate; do
+		COMPREPLY+=("${candidate#"$prefix"}")
+	done < <(PROGRAM __complete "${words[@]:1}" 2>/dev/null)
+}
+complete -o default -F _PROGRAM_completion PROGRAM
+`,
+	"zsh": `#compdef PROGRAM
+# zsh completion for PROGRAM, generated by 'PROGRAM completion zsh'
+_PROGRAM() {
+	local -a candidates
+	candidates=("${(@f)$(PROGRAM __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ ${#candidates} -eq 0 || -z "${candidates[1]}" ]]; then
+		_files
+	else
+		compadd -Q -- "${candidates[@]}"
+	fi
+}
+compdef _PROGRAM PROGRAM
+`,
+	"fish": `# fish completion for PROGRAM, generated by 'PROGRAM completion fish'
+function __PROGRAM_complete
+	set -l tokens (commandline -opc) (commandline -ct)
+	PROGRAM __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c PROGRAM -n 'test (count (__PROGRAM_complete)) -eq 0' -F
+complete -c PROGRAM -n 'test (count (__PROGRAM_complete)) -gt 0' -f -a '(__PROGRAM_complete)'
+`,
+}
+
+// Return the completion script of a shell for [program].
+func CompletionScript(program string, shell string) (string, error) {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return "", fmt.Errorf("Completion is not available for %s (expected %s)", shell, describeChoice(CompletionShells))
+	}
+	return strings.ReplaceAll(script, "PROGRAM", program), nil
+}
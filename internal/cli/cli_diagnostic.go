@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The severity of a diagnostic.
+type Level uint8
+
+const (
+	// A problem that makes the command fail.
+	LevelError Level = iota
+	// A problem that does not prevent the command from succeeding.
+	LevelWarning
+	// A suggestion.
+	LevelHint
+)
+
+func (level Level) String() string {
+	switch level {
+	case LevelError:
+		return "error"
+	case LevelWarning:
+		return "warning"
+	default:
+		return "hint"
+	}
+}
+
+// Return the label of the level, like "Error", and the style it is shown with.
+func (level Level) label() (string, func(*os.File)) {
+	switch level {
+	case LevelError:
+		return "Error", errorStyle
+	case LevelWarning:
+		return "Warning", warningStyle
+	default:
+		return "Hint", hintStyle
+	}
+}
+
+// The part of a file a diagnostic is about. Lines and columns start at 1, and columns are counted in runes.
+type Span struct {
+	File string
+	// The line, or 0 if the diagnostic is about the whole file.
+	Line   int
+	Column int
+	// The number of runes covered by the span. If 0, it covers the word starting at the column.
+	Length int
+}
+
+func (span Span) String() string {
+	if span.Line == 0 {
+		return span.File
+	}
+	return fmt.Sprintf("%s:%d:%d", span.File, span.Line, span.Column)
+}
+
+// A problem found by a command. Diagnostics with a span are shown with the line they point to.
+type Diagnostic struct {
+	Level Level
+	// A stable code identifying the kind of problem, like ELZ0012, or an empty string.
+	Code    string
+	Message string
+	Span    *Span
+	// Messages shown after the snippet, like the way to fix the problem.
+	Hints []string
+	// Other problems shown below the message.
+	Details []error
+}
+
+// Show a diagnostic and count it for the summary.
+func (printer *Printer) Report(diagnostic Diagnostic) {
+	printer.mutex.Lock()
+	printer.report(diagnostic)
+	printer.mutex.Unlock()
+}
+
+func (printer *Printer) report(diagnostic Diagnostic) {
+	printer.counts[diagnostic.Level]++
+	if printer.Output == JSONOutput {
+		printer.emitDiagnostic(diagnostic)
+		return
+	}
+
+	termInfo := GetStderrInfo()
+	label, style := diagnostic.Level.label()
+	if termInfo.SupportsColor && printer.Color {
+		style(os.Stderr)
+	}
+
+	if diagnostic.Code != "" {
+		label += "[" + diagnostic.Code + "]"
+	}
+	fmt.Fprintln(os.Stderr, label+":", diagnostic.Message)
+	if diagnostic.Span != nil {
+		printer.printSnippet(*diagnostic.Span, diagnostic.Hints)
+	} else {
+		for _, hint := range diagnostic.Hints {
+			fprintIndented(os.Stderr, termInfo.Width, 3, "= hint: "+hint)
+		}
+	}
+	for _, detail := range diagnostic.Details {
+		fprintIndented(os.Stderr, termInfo.Width, 3, detail)
+	}
+
+	if termInfo.SupportsColor && printer.Color {
+		resetStyle(os.Stderr)
+	}
+}
+
+// Print the location of a span with the line it points to, followed by hints:
+//
+//	 --> lang/en.elz:3:7
+//	  |
+//	3 | hello  Hello {name
+//	  |              ^^^^^
+//	  = hint: close the placeholder
+func (printer *Printer) printSnippet(span Span, hints []string) {
+	gutter := strings.Repeat(" ", len(fmt.Sprint(span.Line)))
+	fmt.Fprintf(os.Stderr, "%s --> %s\n", gutter, span)
+	if line, ok := printer.sourceLine(span.File, span.Line); ok {
+		text, marker := underline(line, span.Column, span.Length)
+		fmt.Fprintf(os.Stderr, "%s |\n%d | %s\n%s | %s\n", gutter, span.Line, text, gutter, marker)
+	}
+	for _, hint := range hints {
+		fmt.Fprintf(os.Stderr, "%s = hint: %s\n", gutter, hint)
+	}
+}
+
+// Return the line [number] of a file, which is read once. Nothing is returned if the file cannot be read.
+func (printer *Printer) sourceLine(path string, number int) (string, bool) {
+	if printer.sources == nil {
+		printer.sources = make(map[string][]string)
+	}
+	lines, ok := printer.sources[path]
+	if !ok {
+		if content, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		}
+		printer.sources[path] = lines
+	}
+	if number < 1 || number > len(lines) {
+		return "", false
+	}
+	return lines[number-1], true
+}
+
+// Return a line with its tabs expanded, and the carets placed under the [length] runes starting at [column].
+func underline(line string, column int, length int) (text string, marker string) {
+	runes := []rune(line)
+	start := min(max(column-1, 0), len(runes))
+	if length <= 0 {
+		length = 1
+		for start+length < len(runes) && !unicode.IsSpace(runes[start+length]) {
+			length++
+		}
+	}
+
+	var textBuilder, markerBuilder strings.Builder
+	for i, r := range runes {
+		shown := string(r)
+		if r == '\t' {
+			shown = "    "
+		}
+		textBuilder.WriteString(shown)
+		width := utf8.RuneCountInString(shown)
+		switch {
+		case i < start:
+			markerBuilder.WriteString(strings.Repeat(" ", width))
+		case i < start+length:
+			markerBuilder.WriteString(strings.Repeat("^", width))
+		}
+	}
+	if start == len(runes) {
+		// the span is at the end of the line
+		markerBuilder.WriteString("^")
+	}
+	return textBuilder.String(), markerBuilder.String()
+}
+
+// Print the number of diagnostics of each level when there are several of them, so that they can be told apart from
+// the output of the command.
+func (printer *Printer) printSummary() {
+	total := 0
+	for _, count := range printer.counts {
+		total += count
+	}
+	if printer.Output == JSONOutput {
+		if total > 0 {
+			printer.emit("summary", summaryRecord{
+				Errors:   printer.counts[LevelError],
+				Warnings: printer.counts[LevelWarning],
+				Hints:    printer.counts[LevelHint],
+			})
+		}
+		return
+	}
+	if total < 2 {
+		return
+	}
+
+	var counts []string
+	for level, count := range printer.counts {
+		if count > 0 {
+			name := Level(level).String()
+			if count > 1 {
+				name += "s"
+			}
+			counts = append(counts, fmt.Sprintf("%d %s", count, name))
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Summary:", strings.Join(counts, ", "))
+}
+
+// Print the summary of the diagnostics and exit with [reason]. This ends a command after it reported errors.
+func (printer *Printer) Abort(reason ExitReason) {
+	printer.mutex.Lock()
+	printer.printSummary()
+	os.Exit(int(reason))
+}
+
+// Print the summary of the diagnostics at the end of a command, and exit with UserError if errors were reported.
+func (printer *Printer) Finish() {
+	printer.mutex.Lock()
+	printer.printSummary()
+	if printer.counts[LevelError] > 0 {
+		os.Exit(int(UserError))
+	}
+	printer.mutex.Unlock()
+}
+
+func Report(diagnostic Diagnostic) {
+	defaultPrinter.Report(diagnostic)
+}
+
+func Abort(reason ExitReason) {
+	defaultPrinter.Abort(reason)
+}
+
+func Finish() {
+	defaultPrinter.Finish()
+}
@@ -15,18 +15,28 @@ type Printer struct {
 	Program   string
 	Debugging bool
 	Color     bool
+	Output    OutputFormat
+	// the number of diagnostics reported at each level
+	counts [3]int
+	// the lines of the files shown in diagnostics
+	sources map[string][]string
 }
 
 func (printer *Printer) Debug(v ...any) {
 	if printer.Debugging {
 		printer.mutex.Lock()
-		fmt.Fprint(os.Stdout, "dbg ")
+		out := os.Stdout
+		if printer.Output != TextOutput {
+			// the standard output is kept for the objects
+			out = os.Stderr
+		}
+		fmt.Fprint(out, "dbg ")
 		_, file, line, ok := runtime.Caller(2)
 		if ok {
 			lastSegment := strings.LastIndex(file, "/") + 1
-			fmt.Fprint(os.Stdout, "@ ", file[lastSegment:], ":", line, " ")
+			fmt.Fprint(out, "@ ", file[lastSegment:], ":", line, " ")
 		}
-		fmt.Fprintln(os.Stdout, v...)
+		fmt.Fprintln(out, v...)
 		printer.mutex.Unlock()
 	}
 }
@@ -38,32 +48,19 @@ func fprintIndented(f *os.File, maxWidth int, indent int, value any) {
 	}
 }
 
-func (printer *Printer) printErrorMessage(msg string, details ...error) {
-	termInfo := GetStderrInfo()
-	if termInfo.SupportsColor && printer.Color {
-		errorStyle(os.Stderr)
-	}
-
-	fmt.Fprintln(os.Stderr, "Error:", msg)
-	for _, detail := range details {
-		fprintIndented(os.Stderr, termInfo.Width, 3, detail)
-	}
-
-	if termInfo.SupportsColor && printer.Color {
-		resetStyle(os.Stderr)
-	}
+func (printer *Printer) Error(msg string, details ...error) {
+	printer.Report(Diagnostic{Level: LevelError, Message: msg, Details: details})
 }
 
-func (printer *Printer) Error(msg string, details ...error) {
-	printer.mutex.Lock()
-	printer.printErrorMessage(msg, details...)
-	printer.mutex.Unlock()
+func (printer *Printer) Warning(msg string, details ...error) {
+	printer.Report(Diagnostic{Level: LevelWarning, Message: msg, Details: details})
 }
 
 func (printer *Printer) Fatal(msg string, reason ExitReason, details ...error) {
 	printer.mutex.Lock()
-	printer.printErrorMessage(msg, details...)
-	if reason == BadUsage {
+	printer.report(Diagnostic{Level: LevelError, Message: msg, Details: details})
+	printer.printSummary()
+	if reason == BadUsage && printer.Output == TextOutput {
 		fmt.Fprintf(os.Stdout, "run '%s --help' for usage\n", printer.Program)
 	}
 	os.Exit(int(reason))
@@ -83,6 +80,10 @@ func Error(msg string, details ...error) {
 	defaultPrinter.Error(msg, details...)
 }
 
+func Warning(msg string, details ...error) {
+	defaultPrinter.Warning(msg, details...)
+}
+
 func Fatal(msg string, reason ExitReason, details ...error) {
 	defaultPrinter.Fatal(msg, reason, details...)
 }
@@ -91,7 +92,14 @@ func InvalidArgs(errs ...error) {
 	Fatal("invalid command-line arguments", BadUsage, errs...)
 }
 
+// Show a message, unless the output is meant for other programs.
 func Show(msg string) {
+	if defaultPrinter.Output == TextOutput {
+		showText(msg)
+	}
+}
+
+func showText(msg string) {
 	termInfo := GetStdoutInfo()
 	wrapped := wrap.Wrap(msg, termInfo.Width)
 	for _, line := range wrapped {
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// The help of a command, generated from its description, subcommands and flags. It is shown in the terminal, or
+// rendered as a man page or in Markdown.
+type helpPage struct {
+	path        []*Command
+	description string
+	usage       []string
+	aliases     []string
+	details     []string
+	// The subcommands, options and sections of the command, followed by its notes and the global options.
+	sections []Section
+	notes    []string
+	globals  Section
+}
+
+// Collect the help of the last command of [path]. The flags of the root command are listed as global options.
+func newHelpPage(path []*Command) helpPage {
+	root, cmd := path[0], path[len(path)-1]
+	page := helpPage{
+		path:        path,
+		description: cmd.Description,
+		usage:       usageLines(path),
+		details:     cmd.Details,
+		notes:       cmd.Notes,
+		globals:     Section{Title: "Global options", Entries: describeFlags(root)},
+	}
+	if page.description == "" {
+		page.description = cmd.Summary
+	}
+	if len(cmd.Aliases) > 0 {
+		page.aliases = append([]string{cmd.Name}, cmd.Aliases...)
+	}
+
+	if subcommands := describeSubcommands(cmd); len(subcommands) > 0 {
+		title := "Subcommands"
+		if cmd == root {
+			title = "Available commands"
+		}
+		page.sections = append(page.sections, Section{Title: title, Entries: subcommands})
+	}
+	if options := describeFlags(cmd); len(options) > 0 && cmd != root {
+		page.sections = append(page.sections, Section{Title: "Options", Entries: options})
+	}
+	page.sections = append(page.sections, cmd.Sections...)
+	return page
+}
+
+// Show the help of the last command of [path].
+func ShowHelp(path []*Command) {
+	page := newHelpPage(path)
+	ShowUsage(page.description, page.usage...)
+	if len(page.aliases) > 0 {
+		showText("\nAlias: " + strings.Join(page.aliases, ", "))
+	}
+	for _, paragraph := range page.details {
+		showText("\n" + paragraph)
+	}
+	for _, section := range page.sections {
+		showSection(section)
+	}
+	for _, paragraph := range page.notes {
+		showText("\n" + paragraph)
+	}
+	if len(page.globals.Entries) > 0 {
+		showSection(page.globals)
+	}
+}
+
+// Show a section of the help, with its entries aligned.
+func showSection(section Section) {
+	showText("\n" + section.Title + ":")
+	width := 0
+	for _, entry := range section.Entries {
+		width = max(width, utf8.RuneCountInString(entry.Name))
+	}
+	for _, entry := range section.Entries {
+		DescribeOption(entry.Name+strings.Repeat(" ", width-utf8.RuneCountInString(entry.Name)), entry.Description)
+	}
+}
+
+// Return the name of the page of the last command of [path], like "elz-locale-add".
+func PageName(path []*Command) string {
+	return strings.ReplaceAll(CommandPath(path), " ", "-")
+}
+
+// Return the help of the last command of [path] as a man page of section 1. [version] is shown in the footer.
+func ManPage(path []*Command, version string) string {
+	var (
+		page = newHelpPage(path)
+		name = PageName(path)
+		b    strings.Builder
+	)
+	paragraph := func(text string) {
+		fmt.Fprintf(&b, ".PP\n%s\n", escapeRoff(text))
+	}
+	definitions := func(title string, entries []Entry) {
+		fmt.Fprintf(&b, ".SH %s\n", escapeRoff(strings.ToUpper(title)))
+		for _, entry := range entries {
+			fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", escapeRoff(entry.Name), escapeRoff(entry.Description))
+		}
+	}
+
+	fmt.Fprintf(&b, ".TH %s 1 \"\" %q %q\n", strings.ToUpper(name), path[0].Name+" "+version,
+		headerName(path[0])+" Manual")
+	fmt.Fprintf(&b, ".SH NAME\n%s \\- %s\n", escapeRoff(name), escapeRoff(page.description))
+	b.WriteString(".SH SYNOPSIS\n.nf\n")
+	for _, usage := range page.usage {
+		b.WriteString(escapeRoff(usage) + "\n")
+	}
+	b.WriteString(".fi\n")
+	if len(page.aliases) > 0 || len(page.details) > 0 {
+		b.WriteString(".SH DESCRIPTION\n")
+		if len(page.aliases) > 0 {
+			paragraph("Alias: " + strings.Join(page.aliases, ", "))
+		}
+		for _, text := range page.details {
+			paragraph(text)
+		}
+	}
+	for _, section := range page.sections {
+		definitions(section.Title, section.Entries)
+	}
+	if len(page.notes) > 0 {
+		b.WriteString(".SH NOTES\n")
+		for _, text := range page.notes {
+			paragraph(text)
+		}
+	}
+	if len(page.globals.Entries) > 0 {
+		definitions(page.globals.Title, page.globals.Entries)
+	}
+
+	if related := relatedPages(path); len(related) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, other := range related {
+			separator := ","
+			if i == len(related)-1 {
+				separator = ""
+			}
+			fmt.Fprintf(&b, ".BR %s (1)%s\n", escapeRoff(PageName(other)), separator)
+		}
+	}
+	return b.String()
+}
+
+// Escape text for roff, so that it is not taken as a request or an escape sequence.
+func escapeRoff(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// Return the help of the last command of [path] as a Markdown page. Subcommands and related commands link to the pages
+// named after PageName, in the same directory.
+func MarkdownPage(path []*Command) string {
+	var (
+		page = newHelpPage(path)
+		cmd  = path[len(path)-1]
+		b    strings.Builder
+	)
+	definitions := func(title string, entries []Entry, links bool) {
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for i, entry := range entries {
+			term := "`" + entry.Name + "`"
+			if links {
+				sub := visibleSubcommands(cmd)[i]
+				term = fmt.Sprintf("[%s](%s.md)", term, PageName(append(path[:len(path):len(path)], sub)))
+			}
+			fmt.Fprintf(&b, "- %s: %s\n", term, escapeMarkdown(entry.Description))
+		}
+	}
+
+	fmt.Fprintf(&b, "# %s\n\n%s\n\n## Usage\n\n```\n", CommandPath(path), escapeMarkdown(page.description))
+	for _, usage := range page.usage {
+		b.WriteString(usage + "\n")
+	}
+	b.WriteString("```\n")
+	if len(page.aliases) > 0 {
+		fmt.Fprintf(&b, "\nAlias: `%s`\n", strings.Join(page.aliases, "`, `"))
+	}
+	for _, text := range page.details {
+		fmt.Fprintf(&b, "\n%s\n", escapeMarkdown(text))
+	}
+	for i, section := range page.sections {
+		// the subcommands always come first
+		definitions(section.Title, section.Entries, i == 0 && len(visibleSubcommands(cmd)) > 0)
+	}
+	for _, text := range page.notes {
+		fmt.Fprintf(&b, "\n%s\n", escapeMarkdown(text))
+	}
+	if len(page.globals.Entries) > 0 {
+		definitions(page.globals.Title, page.globals.Entries, false)
+	}
+
+	if related := relatedPages(path); len(related) > 0 {
+		b.WriteString("\n## See also\n\n")
+		for _, other := range related {
+			fmt.Fprintf(&b, "- [%s](%s.md)\n", CommandPath(other), PageName(other))
+		}
+	}
+	return b.String()
+}
+
+// Escape the characters of text that Markdown would take as formatting.
+func escapeMarkdown(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "<", `\<`, "[", `\[`).Replace(text)
+}
+
+// Return the paths of the commands a page refers to: the parent of the command, or the commands of the program for
+// the page of the root command.
+func relatedPages(path []*Command) [][]*Command {
+	if len(path) > 1 {
+		return [][]*Command{path[:len(path)-1]}
+	}
+	var related [][]*Command
+	for _, sub := range visibleSubcommands(path[0]) {
+		related = append(related, []*Command{path[0], sub})
+	}
+	return related
+}
+
+// Return the paths of the last command of [path] and all its visible subcommands, to generate a page for each of them.
+func AllPages(path []*Command) [][]*Command {
+	pages := [][]*Command{path}
+	for _, sub := range visibleSubcommands(path[len(path)-1]) {
+		pages = append(pages, AllPages(append(path[:len(path):len(path)], sub))...)
+	}
+	return pages
+}
+
+// Return the name of the program with its first letter in upper case, like "Elz".
+func headerName(root *Command) string {
+	first, size := utf8.DecodeRuneInString(root.Name)
+	return strings.ToUpper(string(first)) + root.Name[size:]
+}
+
+// Return the usage lines of the last command of [path], prefixed with the names of the commands.
+func usageLines(path []*Command) []string {
+	cmd := path[len(path)-1]
+	prefix := CommandPath(path)
+	var lines []string
+
+	if len(cmd.Usage) > 0 {
+		for _, usage := range cmd.Usage {
+			lines = append(lines, prefix+" "+usage)
+		}
+		return lines
+	}
+
+	for _, sub := range visibleSubcommands(cmd) {
+		for _, line := range usageLines(append(path[:len(path):len(path)], sub)) {
+			if sub.Name == cmd.Default {
+				// the name of the default subcommand is optional
+				line = prefix + " [" + sub.Name + "]" + strings.TrimPrefix(line, prefix+" "+sub.Name)
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > 0 {
+		return lines
+	}
+
+	line := prefix
+	if len(describeFlags(cmd)) > 0 {
+		line += " [options]"
+	}
+	for _, arg := range cmd.Args {
+		usage := "<" + arg.Name + ">"
+		if arg.Variadic {
+			usage += "..."
+		}
+		if arg.Optional {
+			usage = "[" + usage + "]"
+		}
+		line += " " + usage
+	}
+	return []string{line}
+}
+
+// Return the subcommands of a command that are not hidden.
+func visibleSubcommands(cmd *Command) []*Command {
+	var subcommands []*Command
+	for _, sub := range cmd.Subcommands {
+		if !sub.Hidden {
+			subcommands = append(subcommands, sub)
+		}
+	}
+	return subcommands
+}
+
+// List the visible subcommands of a command with their aliases.
+func describeSubcommands(cmd *Command) []Entry {
+	var entries []Entry
+	for _, sub := range visibleSubcommands(cmd) {
+		name := strings.Join(append([]string{sub.Name}, sub.Aliases...), ", ")
+		entries = append(entries, Entry{Name: name, Description: sub.Summary})
+	}
+	return entries
+}
+
+// List the visible flags of a command, like "-L, --locale <locale>".
+func describeFlags(cmd *Command) []Entry {
+	var entries []Entry
+	for _, flag := range cmd.Flags {
+		if !flag.Hidden {
+			entries = append(entries, Entry{Name: sprintFlagUsage(flag), Description: flag.Usage})
+		}
+	}
+	return entries
+}
+
+// Print a flag as it is written in the help.
+func sprintFlagUsage(flag *Flag) string {
+	usage := "--" + flag.Name
+	if flag.Shorthand != "" {
+		usage = fmt.Sprintf("-%s, %s", flag.Shorthand, usage)
+	}
+	if flag.Scope != "" {
+		usage += "[:<" + flag.Scope + ">]"
+	}
+	if flag.Value != "" {
+		usage += " <" + flag.Value + ">"
+	}
+	return usage
+}
@@ -57,6 +57,14 @@ func errorStyle(f *os.File) {
    f.WriteString("\x1b[91m")
 }
 
+func warningStyle(f *os.File) {
+   f.WriteString("\x1b[93m")
+}
+
+func hintStyle(f *os.File) {
+   f.WriteString("\x1b[96m")
+}
+
 func resetStyle(f *os.File) {
    f.WriteString("\x1b[0m")
 }
@@ -11,4 +11,8 @@ func GetTerminalInfo(*os.File) TerminalInfo {
 
 func errorStyle(*os.File) { }
 
+func warningStyle(*os.File) { }
+
+func hintStyle(*os.File) { }
+
 func resetStyle(*os.File) { }
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// The format of what a program writes to the standard output.
+type OutputFormat uint8
+
+const (
+	// Text meant to be read by humans.
+	TextOutput OutputFormat = iota
+	// One JSON object per line (NDJSON), to be consumed by other programs. Show writes nothing, and the results of
+	// commands are given to Emit instead.
+	JSONOutput
+)
+
+// The names of the output formats, in the order of their values.
+var OutputFormats = []string{"text", "json"}
+
+// The version of the objects written with JSONOutput, given in their "schemaVersion" field. It is increased when a
+// field is removed or changes meaning, but not when fields or types of objects are added.
+const OutputVersion = 1
+
+// Write an object of type [kind] with the fields of [record], which is usually a struct with json tags. The object
+// also has a "type" and a "schemaVersion" field. Nothing is written with TextOutput.
+func (printer *Printer) Emit(kind string, record any) {
+	if printer.Output != JSONOutput {
+		return
+	}
+	printer.mutex.Lock()
+	printer.emit(kind, record)
+	printer.mutex.Unlock()
+}
+
+func (printer *Printer) emit(kind string, record any) {
+	fields, err := json.Marshal(record)
+	if err != nil || len(fields) < 2 || fields[0] != '{' {
+		panic(fmt.Sprintf("cannot emit %T as a JSON object: %v", record, err))
+	}
+	header, _ := json.Marshal(kind)
+	line := fmt.Sprintf(`{"type":%s,"schemaVersion":%d`, header, OutputVersion)
+	if len(fields) > 2 {
+		line += ","
+	}
+	fmt.Fprintln(os.Stdout, line+string(fields[1:]))
+}
+
+// A Diagnostic, as written with JSONOutput.
+type diagnosticRecord struct {
+	Level   string      `json:"level"`
+	Code    string      `json:"code,omitempty"`
+	Message string      `json:"message"`
+	Span    *spanRecord `json:"span,omitempty"`
+	Hints   []string    `json:"hints,omitempty"`
+	Details []string    `json:"details"`
+}
+
+type spanRecord struct {
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	Length int    `json:"length,omitempty"`
+}
+
+// The number of diagnostics of each level, written at the end of a command that reported some.
+type summaryRecord struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	Hints    int `json:"hints"`
+}
+
+func (printer *Printer) emitDiagnostic(diagnostic Diagnostic) {
+	record := diagnosticRecord{
+		Level:   diagnostic.Level.String(),
+		Code:    diagnostic.Code,
+		Message: diagnostic.Message,
+		Hints:   diagnostic.Hints,
+		Details: make([]string, len(diagnostic.Details)),
+	}
+	if span := diagnostic.Span; span != nil {
+		record.Span = &spanRecord{File: span.File, Line: span.Line, Column: span.Column, Length: span.Length}
+	}
+	for i, detail := range diagnostic.Details {
+		record.Details[i] = detail.Error()
+	}
+	printer.emit("diagnostic", record)
+}
+
+func Emit(kind string, record any) {
+	defaultPrinter.Emit(kind, record)
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var stdinReader = sync.OnceValue(func() *bufio.Reader {
+	return bufio.NewReader(os.Stdin)
+})
+
+// Read a line from the standard input. Return false if the input is closed.
+func readAnswer() (string, bool) {
+	line, err := stdinReader().ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+// Ask a question on the standard input. Return [value] if the answer is empty.
+func Ask(question string, value string) string {
+	if value != "" {
+		fmt.Printf("%s (%s): ", question, value)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	if answer, ok := readAnswer(); ok && answer != "" {
+		return answer
+	}
+	return value
+}
+
+// Ask for a list of values separated by commas or spaces. Return [values] if the answer is empty, or no values if
+// the answer is a single dash.
+func AskList(question string, values []string) []string {
+	answer := Ask(question, strings.Join(values, ", "))
+	if answer == "-" {
+		return nil
+	}
+	return strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// Ask a yes or no question. Return [value] if the answer is empty.
+func Confirm(question string, value bool) bool {
+	choices := "y/N"
+	if value {
+		choices = "Y/n"
+	}
+	for {
+		fmt.Printf("%s [%s]: ", question, choices)
+		answer, ok := readAnswer()
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "":
+			return value
+		}
+		if !ok {
+			return value
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// A command of a program, with its flags, positional arguments and subcommands. The root command is the program
+// itself, and its flags are accepted by every command.
+type Command struct {
+	Name    string
+	Aliases []string
+	// A short description, shown in the list of subcommands of the parent.
+	Summary string
+	// The explanation shown at the top of the help of the command. Defaults to the summary.
+	Description string
+	// The usage lines of the command, without its name. If empty, they are generated from the flags and arguments, or
+	// from the subcommands.
+	Usage []string
+	// Paragraphs shown in the help after the usage, and lists shown after the options.
+	Details  []string
+	Sections []Section
+	// Paragraphs shown at the end of the help.
+	Notes []string
+	// Hidden commands are neither listed in the help nor completed.
+	Hidden      bool
+	Flags       []*Flag
+	Args        []*Arg
+	Subcommands []*Command
+	// The name of the subcommand to run when none is given. Without default, a subcommand is required.
+	Default string
+	// Run the command with the arguments left after the names of the commands. Commands with subcommands can leave it
+	// empty.
+	Run func(args Args)
+}
+
+// A flag of a command.
+type Flag struct {
+	Name      string
+	Shorthand string
+	// The name of the value of the flag, like "locale", or an empty string for boolean flags.
+	Value string
+	// The name of the suffix of the flag, like "prefix" for --prune:ui, or an empty string if it cannot have one.
+	Scope string
+	// Return the suffixes the flag accepts, or <nil> if they cannot be listed.
+	Scopes func() []string
+	// Return the values the flag can take, or <nil> if they cannot be listed.
+	Complete func() []string
+	// The description of the flag in the help.
+	Usage string
+	// Hidden flags are completed but not listed in the help.
+	Hidden bool
+}
+
+// A positional argument of a command.
+type Arg struct {
+	Name string
+	// Wether the argument can be left out.
+	Optional bool
+	// Wether the argument can be repeated. Only the last argument of a command can be variadic.
+	Variadic bool
+	// Return the values the argument can take, or <nil> if they cannot be listed.
+	Complete func() []string
+}
+
+// A list of definitions in the help of a command, like the targets of a build.
+type Section struct {
+	Title   string
+	Entries []Entry
+}
+
+// A term and its description.
+type Entry struct {
+	Name        string
+	Description string
+}
+
+// Return the subcommand named [name] or one of its aliases, or <nil> if there is none.
+func (cmd *Command) Subcommand(name string) *Command {
+	for _, sub := range cmd.Subcommands {
+		if sub.Name == name || slices.Contains(sub.Aliases, name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Return the flag named [name], which may be a shorthand, or <nil> if there is none.
+func (cmd *Command) Flag(name string) *Flag {
+	for _, flag := range cmd.Flags {
+		if flag.Name == name || flag.Shorthand != "" && flag.Shorthand == name {
+			return flag
+		}
+	}
+	return nil
+}
+
+// Return the argument at [position], or <nil> if there is none.
+func (cmd *Command) Arg(position int) *Arg {
+	if position < len(cmd.Args) {
+		return cmd.Args[position]
+	}
+	if len(cmd.Args) > 0 && cmd.Args[len(cmd.Args)-1].Variadic {
+		return cmd.Args[len(cmd.Args)-1]
+	}
+	return nil
+}
+
+// Find the command named by [args] below [root], and return the path from the root to it. The names of the command and
+// its subcommands are consumed, and the values of the flags known at that point are skipped. Unknown commands are
+// fatal, with a suggestion if a command has a close name.
+func (root *Command) Find(args *Args) []*Command {
+	path := []*Command{root}
+	DefaultPrinter().Program = root.Name
+	for cmd := root; len(cmd.Subcommands) > 0; {
+		arg := args.nextCommand(path)
+		if arg == nil {
+			break
+		}
+		sub := cmd.Subcommand(arg.value)
+		if sub == nil {
+			cmd.unknownSubcommand(arg.value, cmd == root)
+		}
+		arg.wasUsed = true
+		cmd = sub
+		path = append(path, cmd)
+		DefaultPrinter().Program = CommandPath(path)
+	}
+	return path
+}
+
+// Return the path from [root] to the command named by [names], like "locale" and "add" for elz locale add. Unknown
+// commands are fatal like in Find.
+func (root *Command) Lookup(names ...string) []*Command {
+	path := []*Command{root}
+	for _, name := range names {
+		cmd := path[len(path)-1]
+		sub := cmd.Subcommand(name)
+		if sub == nil {
+			cmd.unknownSubcommand(name, cmd == root)
+		}
+		path = append(path, sub)
+	}
+	return path
+}
+
+// Fail because [name] is not a subcommand of [cmd], suggesting a close name if there is one.
+func (cmd *Command) unknownSubcommand(name string, isRoot bool) {
+	kind := "subcommand"
+	if isRoot {
+		kind = "command"
+	}
+	msg := fmt.Sprintf("unknown %s \"%s\"", kind, name)
+	if suggestion := cmd.suggest(name); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean '%s'?)", suggestion)
+	}
+	Fatal(msg, BadUsage)
+}
+
+// Return the next argument that may be a command, skipping the values of the flags of the commands in [path].
+func (args *Args) nextCommand(path []*Command) *Argument {
+	skip := false
+	for i := range *args {
+		arg := &(*args)[i]
+		switch {
+		case skip:
+			skip = false
+		case arg.wasUsed:
+		case arg.isFlag:
+			skip = !arg.hasValue && slices.ContainsFunc(path, func(cmd *Command) bool {
+				flag := cmd.Flag(arg.name)
+				return flag != nil && flag.Value != ""
+			})
+		case arg.mayBeCommand:
+			return arg
+		}
+	}
+	return nil
+}
+
+// Run the last command of [path] with [args]. If it has no Run function, its default subcommand is run instead, and
+// the help of the root command is shown when it is run without a command.
+func Run(path []*Command, args Args) {
+	cmd := path[len(path)-1]
+	if cmd.Run == nil && cmd.Default != "" {
+		cmd = cmd.Subcommand(cmd.Default)
+		path = append(path, cmd)
+		DefaultPrinter().Program = CommandPath(path)
+	}
+	switch {
+	case cmd.Run != nil:
+		cmd.Run(args)
+	case len(path) == 1:
+		args.Done()
+		ShowHelp(path)
+	default:
+		var names []string
+		for _, sub := range cmd.Subcommands {
+			if !sub.Hidden {
+				names = append(names, sub.Name)
+			}
+		}
+		InvalidArgs(fmt.Errorf("Expected a subcommand (%s)", describeChoice(names)))
+	}
+}
+
+// Return the names of the commands of [path] separated by spaces, like "elz locale add".
+func CommandPath(path []*Command) string {
+	names := make([]string, len(path))
+	for i, cmd := range path {
+		names[i] = cmd.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// Return the name of the visible subcommand closest to [word], or an empty string if none is close enough to be a
+// typo. Aliases are compared too, but the name of the command is suggested.
+func (cmd *Command) suggest(word string) string {
+	var (
+		suggestion string
+		best       = max(1, utf8.RuneCountInString(word)/3) + 1
+	)
+	for _, sub := range cmd.Subcommands {
+		if sub.Hidden {
+			continue
+		}
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			if distance := editDistance(word, name); distance < best {
+				suggestion, best = sub.Name, distance
+			}
+		}
+	}
+	return suggestion
+}
+
+// Return the number of insertions, deletions, substitutions and transpositions of adjacent characters needed to turn
+// [a] into [b].
+func editDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] is the distance between the first i characters of s and the first j characters of t
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// Return the candidates for the last of [words], which are the arguments following the program name. Flags, flag
+// values, subcommands and arguments are completed according to [root].
+func Complete(root *Command, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	var (
+		cmd      = root
+		pending  *Flag
+		position int
+		afterEnd bool
+	)
+	lookupFlag := func(word string) *Flag {
+		name := strings.TrimLeft(word, "-")
+		name, _, _ = strings.Cut(name, "=")
+		name, _, _ = strings.Cut(name, ":")
+		if !strings.HasPrefix(word, "--") && name != "" {
+			// the value is given to the last flag of a group of shorthands
+			name = name[len(name)-1:]
+		}
+		if flag := cmd.Flag(name); flag != nil {
+			return flag
+		}
+		return root.Flag(name)
+	}
+
+	for _, word := range words[:len(words)-1] {
+		switch {
+		case pending != nil:
+			pending = nil
+		case word == "--":
+			afterEnd = true
+		case !afterEnd && len(word) > 1 && strings.HasPrefix(word, "-"):
+			if flag := lookupFlag(word); flag != nil && flag.Value != "" && !strings.Contains(word, "=") {
+				pending = flag
+			}
+		case position == 0 && cmd.Subcommand(word) != nil:
+			cmd = cmd.Subcommand(word)
+		default:
+			position++
+		}
+	}
+
+	current := words[len(words)-1]
+	var candidates []string
+	switch {
+	case pending != nil:
+		candidates = completeValues(pending, "")
+	case !afterEnd && strings.HasPrefix(current, "-"):
+		if name, _, hasValue := strings.Cut(current, "="); hasValue {
+			if flag := lookupFlag(name); flag != nil {
+				candidates = completeValues(flag, name+"=")
+			}
+		} else if name, _, hasSuffix := strings.Cut(current, ":"); hasSuffix {
+			if flag := lookupFlag(name); flag != nil && flag.Scopes != nil {
+				for _, scope := range flag.Scopes() {
+					candidates = append(candidates, name+":"+scope)
+				}
+			}
+		} else {
+			for _, flags := range [][]*Flag{cmd.Flags, root.Flags} {
+				for _, flag := range flags {
+					candidates = append(candidates, "--"+flag.Name)
+				}
+				if cmd == root {
+					break
+				}
+			}
+		}
+	case position == 0 && len(cmd.Subcommands) > 0:
+		for _, sub := range cmd.Subcommands {
+			if !sub.Hidden {
+				candidates = append(candidates, sub.Name)
+			}
+		}
+	default:
+		if arg := cmd.Arg(position); arg != nil && arg.Complete != nil {
+			candidates = arg.Complete()
+		}
+	}
+
+	return slices.DeleteFunc(candidates, func(candidate string) bool {
+		return !strings.HasPrefix(candidate, current)
+	})
+}
+
+// List the values of a flag, with a prefix.
+func completeValues(flag *Flag, prefix string) []string {
+	if flag.Complete == nil {
+		return nil
+	}
+	values := flag.Complete()
+	for i := range values {
+		values[i] = prefix + values[i]
+	}
+	return values
+}
@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Parse [words] as if they were given to the program on the command line.
+func parseWords(words ...string) Args {
+	saved := os.Args
+	defer func() { os.Args = saved }()
+	os.Args = append([]string{"elz"}, words...)
+	return ParseArgs()
+}
+
+// Return what [f] writes to [file], which is os.Stdout or os.Stderr.
+func capture(t *testing.T, file **os.File, f func()) string {
+	tmp, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatalf("error creating a temporary file: %s", err)
+	}
+	saved := *file
+	*file = tmp
+	f()
+	*file = saved
+	tmp.Close()
+
+	content, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		t.Fatalf("error reading the output: %s", err)
+	}
+	return string(content)
+}
+
+func TestStringSliceFlag(t *testing.T) {
+	args := parseWords("--config", "a=1", "--config=b=2", "-c", "c=3", "x")
+	values, err := args.StringSliceFlag("config", "c")
+	if err != nil || !slices.Equal(values, []string{"a=1", "b=2", "c=3"}) {
+		t.Fatalf("Expected [a=1 b=2 c=3] but got %v (%v)", values, err)
+	}
+	if rest := args.Rest(); !slices.Equal(rest, []string{"x"}) {
+		t.Fatalf("Expected the values to be taken from the arguments but got %v left", rest)
+	}
+
+	for _, words := range [][]string{{"--config"}, {"--config", "--check"}} {
+		args := parseWords(words...)
+		_, err := args.StringSliceFlag("config", "c")
+		if err == nil || err.Error() != `Flag "-c" or "--config" requires a value` {
+			t.Fatalf("Expected %v to be missing a value but got %v", words, err)
+		}
+	}
+
+	args = parseWords("--check", "--check")
+	if _, err := args.BoolFlag("check", "", true); err == nil {
+		t.Fatal("Expected a repeated boolean flag to be an error")
+	}
+}
+
+func TestStringFlag(t *testing.T) {
+	for _, words := range [][]string{{"--locale=fr", "x"}, {"--locale", "fr", "x"}, {"-L", "fr", "x"}} {
+		args := parseWords(words...)
+		locale, err := args.StringFlag("locale", "L", "en")
+		if err != nil || locale != "fr" {
+			t.Fatalf("Expected %v to set the locale to fr but got %q (%v)", words, locale, err)
+		}
+		if rest := args.Rest(); !slices.Equal(rest, []string{"x"}) {
+			t.Fatalf("Expected %v to leave [x] but got %v", words, rest)
+		}
+	}
+
+	args := parseWords("x")
+	if locale, err := args.StringFlag("locale", "L", "en"); err != nil || locale != "en" {
+		t.Fatalf("Expected a missing flag to be the default but got %q (%v)", locale, err)
+	}
+	args = parseWords("--locale", "fr", "-L", "de")
+	_, err := args.StringFlag("locale", "L", "en")
+	if err == nil || err.Error() != `Flag "-L" or "--locale" can only be used once` {
+		t.Fatalf("Expected a repeated flag to be an error but got %v", err)
+	}
+}
+
+func TestFlagValues(t *testing.T) {
+	args := parseWords("--check=yes", "-j", "four", "--output", "xml")
+	if _, err := args.BoolFlag("check", "", true); err == nil || err.Error() != `Flag "--check" cannot have a value` {
+		t.Fatalf("Expected a boolean flag with a value to be an error but got %v", err)
+	}
+	_, err := args.IntFlag("jobs", "j", 1)
+	if err == nil || err.Error() != `Flag "-j" or "--jobs" expects an integer but got "four"` {
+		t.Fatalf("Expected an invalid integer to be an error but got %v", err)
+	}
+	_, err = args.EnumFlag("output", "", "text", "text", "json")
+	if err == nil || err.Error() != `Flag "--output" expects text or json but got "xml"` {
+		t.Fatalf("Expected an invalid choice to be an error but got %v", err)
+	}
+
+	args = parseWords("-j", "4")
+	if jobs, err := args.IntFlag("jobs", "j", 1); err != nil || jobs != 4 {
+		t.Fatalf("Expected -j 4 to be 4 but got %v (%v)", jobs, err)
+	}
+
+	args = parseWords("--jobs=", "--output=")
+	_, err = args.IntFlag("jobs", "j", 1)
+	if err == nil || err.Error() != `Flag "-j" or "--jobs" expects a value` {
+		t.Fatalf("Expected an empty integer to be an error but got %v", err)
+	}
+	_, err = args.EnumFlag("output", "", "text", "text", "json")
+	if err == nil || err.Error() != `Flag "--output" expects a value` {
+		t.Fatalf("Expected an empty choice to be an error but got %v", err)
+	}
+}
+
+func TestPositionals(t *testing.T) {
+	args := parseWords("a")
+	if value, err := args.Positional("locale"); err != nil || value != "a" {
+		t.Fatalf("Expected the first argument to be a but got %q (%v)", value, err)
+	}
+	if _, err := args.Positional("text"); err == nil || err.Error() != "Missing <text> argument" {
+		t.Fatalf("Expected a missing argument to be an error but got %v", err)
+	}
+
+	cases := []struct {
+		minimum, maximum int
+		expected         string
+	}{
+		{1, 2, "Expected between 1 and 2 <file> argument(s) but got 3"},
+		{2, 2, "Expected exactly 2 <file> argument(s) but got 3"},
+		{0, 1, "Expected at most 1 <file> argument(s) but got 3"},
+		{4, -1, "Expected at least 4 <file> argument(s) but got 3"},
+	}
+	for _, c := range cases {
+		args := parseWords("a", "b", "c")
+		_, err := args.Positionals("file", c.minimum, c.maximum)
+		if err == nil || err.Error() != c.expected {
+			t.Fatalf("Expected %q but got %v", c.expected, err)
+		}
+	}
+
+	args = parseWords("a", "b", "c")
+	if values, err := args.Positionals("file", 0, -1); err != nil || len(values) != 3 {
+		t.Fatalf("Expected 3 arguments but got %v (%v)", values, err)
+	}
+}
+
+func TestScopedFlags(t *testing.T) {
+	args := parseWords("--output=all.js", "--output:fr=fr.js", "--output:de", "de.js")
+	outputs, err := args.ScopedStringFlag("output", "o")
+	expected := map[string]string{"": "all.js", "fr": "fr.js", "de": "de.js"}
+	if err != nil || !maps.Equal(outputs, expected) {
+		t.Fatalf("Expected %v but got %v (%v)", expected, outputs, err)
+	}
+
+	args = parseWords("--output:fr=a.js", "--output:fr=b.js")
+	_, err = args.ScopedStringFlag("output", "o")
+	if err == nil || err.Error() != `Flag "--output:fr" can only be used once` {
+		t.Fatalf("Expected a repeated suffix to be an error but got %v", err)
+	}
+
+	args = parseWords("--prune:ui", "--prune:$")
+	prune, err := args.ScopedBoolFlag("prune", "", true)
+	if err != nil || !maps.Equal(prune, map[string]bool{"ui": true, "$": true}) || prune[""] {
+		t.Fatalf("Expected the ui and $ prefixes to be pruned but got %v (%v)", prune, err)
+	}
+	args = parseWords("--check:ui")
+	if _, err := args.BoolFlag("check", "", true); err == nil {
+		t.Fatal("Expected a suffix on an unscoped flag to be an error")
+	}
+}
+
+func testCommands() *Command {
+	return &Command{
+		Name: "elz",
+		Flags: []*Flag{
+			{Name: "output", Value: "format", Complete: func() []string { return []string{"text", "json"} }},
+			{Name: "project", Value: "dir"},
+			{Name: "help", Shorthand: "h"},
+		},
+		Subcommands: []*Command{
+			{
+				Name:        "locale",
+				Subcommands: []*Command{{Name: "list"}, {Name: "add", Aliases: []string{"new"}}},
+			},
+			{
+				Name: "update",
+				Flags: []*Flag{
+					{Name: "check"},
+					{Name: "prune", Scopes: func() []string { return []string{"$", "ui"} }},
+				},
+			},
+			{Name: "format", Args: []*Arg{{Name: "file", Complete: func() []string { return []string{"en.elz"} }}}},
+			{Name: "config", Hidden: true},
+		},
+	}
+}
+
+func TestComplete(t *testing.T) {
+	cases := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{""}, []string{"locale", "update", "format"}},
+		{[]string{"lo"}, []string{"locale"}},
+		{[]string{"con"}, nil},
+		{[]string{"locale", ""}, []string{"list", "add"}},
+		{[]string{"--project", "dir", "locale", "a"}, []string{"add"}},
+		{[]string{"--output", ""}, []string{"text", "json"}},
+		{[]string{"--output=j"}, []string{"--output=json"}},
+		{[]string{"update", "--p"}, []string{"--prune", "--project"}},
+		{[]string{"update", "--prune:"}, []string{"--prune:$", "--prune:ui"}},
+		{[]string{"format", ""}, []string{"en.elz"}},
+		{[]string{"format", "en.elz", ""}, nil},
+	}
+	for _, c := range cases {
+		if candidates := Complete(testCommands(), c.words); !slices.Equal(candidates, c.expected) {
+			t.Fatalf("Expected %v to be completed with %v but got %v", c.words, c.expected, candidates)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	args := parseWords("--project", "locale", "locale", "new", "fr")
+	path := testCommands().Find(&args)
+	if CommandPath(path) != "elz locale add" {
+		t.Fatalf("Expected the path to be elz locale add but got %s", CommandPath(path))
+	}
+	if project, err := args.StringFlag("project", "", ""); err != nil || project != "locale" {
+		t.Fatalf("Expected the value of --project to be left to the flag but got %q (%v)", project, err)
+	}
+	if rest := args.Rest(); !slices.Equal(rest, []string{"fr"}) {
+		t.Fatalf("Expected the arguments of the command to be [fr] but got %v", rest)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	root := testCommands()
+	cases := map[string]string{
+		"fromat": "format",
+		"updat":  "update",
+		"nwe":    "",
+		"confg":  "",
+		"xyz":    "",
+	}
+	for word, expected := range cases {
+		if suggestion := root.suggest(word); suggestion != expected {
+			t.Fatalf("Expected %q to suggest %q but got %q", word, expected, suggestion)
+		}
+	}
+	if suggestion := root.Subcommand("locale").suggest("nwe"); suggestion != "add" {
+		t.Fatalf("Expected aliases to suggest their command but got %q", suggestion)
+	}
+	if distance := editDistance("ab", "ba"); distance != 1 {
+		t.Fatalf("Expected a transposition to count as one edit but got %d", distance)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	printer := &Printer{Output: JSONOutput}
+	output := capture(t, &os.Stdout, func() {
+		printer.Emit("locale", struct {
+			Name  string `json:"name"`
+			Files int    `json:"files"`
+		}{"fr", 2})
+		printer.Emit("done", struct{}{})
+	})
+	expected := `{"type":"locale","schemaVersion":1,"name":"fr","files":2}` + "\n" +
+		`{"type":"done","schemaVersion":1}` + "\n"
+	if output != expected {
+		t.Fatalf("Expected %q but got %q", expected, output)
+	}
+
+	printer = &Printer{Output: TextOutput}
+	if output := capture(t, &os.Stdout, func() { printer.Emit("done", struct{}{}) }); output != "" {
+		t.Fatalf("Expected nothing to be emitted with text output but got %q", output)
+	}
+}
+
+func TestUnderline(t *testing.T) {
+	cases := []struct {
+		line           string
+		column, length int
+		text, marker   string
+	}{
+		{"hello  Hello {name", 14, 5, "hello  Hello {name", "             ^^^^^"},
+		{"hello  Hello", 8, 0, "hello  Hello", "       ^^^^^"},
+		{"\tkey  text", 2, 3, "    key  text", "    ^^^"},
+		{"ééé T(k)", 5, 4, "ééé T(k)", "    ^^^^"},
+		{"end", 4, 0, "end", "   ^"},
+	}
+	for _, c := range cases {
+		text, marker := underline(c.line, c.column, c.length)
+		if text != c.text || marker != c.marker {
+			t.Fatalf("Expected %q to be underlined as %q but got %q", c.line, c.marker, marker)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "en.elz")
+	if err := os.WriteFile(source, []byte("used  Used\nbad  Hello {name\n"), 0o644); err != nil {
+		t.Fatalf("error writing the source: %s", err)
+	}
+
+	printer := &Printer{}
+	output := capture(t, &os.Stderr, func() {
+		printer.Report(Diagnostic{
+			Level: LevelError, Code: "ELZ0001", Message: "unclosed placeholder",
+			Span:  &Span{File: source, Line: 2, Column: 12, Length: 5},
+			Hints: []string{"close the placeholder"},
+		})
+		printer.Report(Diagnostic{Level: LevelWarning, Message: "first"})
+		printer.Report(Diagnostic{Level: LevelWarning, Message: "second"})
+		printer.printSummary()
+	})
+	expected := "Error[ELZ0001]: unclosed placeholder\n" +
+		"  --> " + source + ":2:12\n" +
+		"  |\n" +
+		"2 | bad  Hello {name\n" +
+		"  |            ^^^^^\n" +
+		"  = hint: close the placeholder\n" +
+		"Warning: first\n" +
+		"Warning: second\n" +
+		"Summary: 1 error, 2 warnings\n"
+	if output != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, output)
+	}
+
+	printer = &Printer{}
+	output = capture(t, &os.Stderr, func() {
+		printer.Report(Diagnostic{Level: LevelHint, Message: "only one"})
+		printer.printSummary()
+	})
+	if strings.Contains(output, "Summary") {
+		t.Fatalf("Expected no summary for a single diagnostic but got %q", output)
+	}
+
+	printer = &Printer{Output: JSONOutput}
+	output = capture(t, &os.Stdout, func() {
+		printer.Report(Diagnostic{Level: LevelWarning, Code: "ELZ0004", Message: "unused", Span: &Span{File: "en.elz"}})
+		printer.printSummary()
+	})
+	expected = `{"type":"diagnostic","schemaVersion":1,"level":"warning","code":"ELZ0004","message":"unused",` +
+		`"span":{"file":"en.elz"},"details":[]}` + "\n" +
+		`{"type":"summary","schemaVersion":1,"errors":0,"warnings":1,"hints":0}` + "\n"
+	if output != expected {
+		t.Fatalf("Expected %q but got %q", expected, output)
+	}
+}
@@ -73,4 +73,8 @@ func GetTerminalInfo(file *os.File) TerminalInfo {
 
 func errorStyle(*os.File) { }
 
+func warningStyle(*os.File) { }
+
+func hintStyle(*os.File) { }
+
 func resetStyle(*os.File) { }
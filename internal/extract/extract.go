@@ -0,0 +1,118 @@
+// Extraction of message keys from source files.
+package extract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A call to the translate function found in a source file. Lines and columns start at 1, and columns are counted in
+// runes.
+type Call struct {
+	Path   string
+	Line   int
+	Column int
+	// The key of the message, or an empty string if the call is dynamic.
+	Key string
+	// Wether the key is not a string literal, in which case it cannot be extracted.
+	Dynamic bool
+	// The byte offsets of the key in the source, including its quotes.
+	Start int
+	End   int
+}
+
+func (call Call) Position() string {
+	return fmt.Sprintf("%s:%d:%d", call.Path, call.Line, call.Column)
+}
+
+// The names of the translate functions for each language.
+type Options struct {
+	GoTranslateFn string
+	JSTranslateFn string
+}
+
+// The extensions of JavaScript and TypeScript files.
+var jsExtensions = []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts"}
+
+// Return wether the messages of a file can be extracted.
+func IsSupported(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".go" || slices.Contains(jsExtensions, ext)
+}
+
+// Find the calls to the translate function in a file. Files that are not supported are ignored.
+func File(path string, opts Options) ([]Call, error) {
+	if !IsSupported(path) {
+		return nil, nil
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(path)
+	if ext == ".go" {
+		return Go(path, src, opts.GoTranslateFn)
+	}
+	return JavaScript(path, src, opts.JSTranslateFn, allowsJSX(ext)), nil
+}
+
+// Find the calls to the translate function in multiple files. All the files are read even if some of them fail.
+func Files(paths []string, opts Options) ([]Call, []error) {
+	var (
+		calls []Call
+		errs  []error
+	)
+	for _, path := range paths {
+		found, err := File(path, opts)
+		calls = append(calls, found...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return calls, errs
+}
+
+// Return the keys of the calls in order of first appearance.
+func Keys(calls []Call) []string {
+	var (
+		keys []string
+		seen = make(map[string]bool)
+	)
+	for _, call := range calls {
+		if !call.Dynamic && !seen[call.Key] {
+			keys = append(keys, call.Key)
+			seen[call.Key] = true
+		}
+	}
+	return keys
+}
+
+// Sort the files of the calls, in order of first appearance, into the files where every key starts with [keyPrefix]
+// and the files that also have other keys or dynamic calls. Files where no key starts with [keyPrefix] are left out.
+func SplitPaths(calls []Call, keyPrefix string) (only []string, mixed []string) {
+	var (
+		paths   []string
+		matched = make(map[string]int)
+		total   = make(map[string]int)
+	)
+	for _, call := range calls {
+		if total[call.Path] == 0 {
+			paths = append(paths, call.Path)
+		}
+		total[call.Path]++
+		if !call.Dynamic && strings.HasPrefix(call.Key, keyPrefix) {
+			matched[call.Path]++
+		}
+	}
+	for _, path := range paths {
+		if matched[path] == total[path] {
+			only = append(only, path)
+		} else if matched[path] > 0 {
+			mixed = append(mixed, path)
+		}
+	}
+	return only, mixed
+}
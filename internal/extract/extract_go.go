@@ -0,0 +1,55 @@
+package extract
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Find the calls to [translateFn] in Go code. Both T("key") and pkg.T("key") are recognised.
+func Go(path string, src []byte, translateFn string) ([]Call, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []Call
+	ast.Inspect(file, func(node ast.Node) bool {
+		expr, ok := node.(*ast.CallExpr)
+		if !ok || !isGoFunc(expr.Fun, translateFn) {
+			return true
+		}
+
+		pos := fset.Position(expr.Pos())
+		// go/token counts columns in bytes
+		column := utf8.RuneCount(src[pos.Offset-pos.Column+1:pos.Offset]) + 1
+		call := Call{Path: path, Line: pos.Line, Column: column, Dynamic: true}
+		if len(expr.Args) > 0 {
+			call.Start = fset.Position(expr.Args[0].Pos()).Offset
+			call.End = fset.Position(expr.Args[0].End()).Offset
+			if lit, ok := expr.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if key, err := strconv.Unquote(lit.Value); err == nil {
+					call.Key = key
+					call.Dynamic = false
+				}
+			}
+		}
+		calls = append(calls, call)
+		return true
+	})
+	return calls, nil
+}
+
+func isGoFunc(fun ast.Expr, name string) bool {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun.Name == name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name == name
+	default:
+		return false
+	}
+}
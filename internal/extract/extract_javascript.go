@@ -0,0 +1,401 @@
+package extract
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type jsTokenKind uint8
+
+const (
+	jsIdent jsTokenKind = iota
+	jsString
+	jsTemplate
+	jsNumber
+	jsPunct
+	jsRegex
+	// a whole JSX element, which is an expression
+	jsElement
+)
+
+type jsToken struct {
+	kind jsTokenKind
+	// the name of identifiers and punctuators, or the value of strings and templates without substitutions
+	text       string
+	start, end int
+	// wether a template has substitutions, in which case its value is unknown
+	substituted bool
+}
+
+// Keywords after which a slash starts a regular expression instead of being a division.
+var jsKeywordsBeforeExpr = []string{
+	"return", "typeof", "instanceof", "in", "of", "new", "delete", "void", "throw", "case", "do", "else", "yield",
+	"await",
+}
+
+// Splits JavaScript or TypeScript code into tokens. Comments, whitespace and the text of JSX elements are skipped,
+// but the expressions inside template literals and JSX elements are tokenized.
+type jsScanner struct {
+	src    string
+	i      int
+	jsx    bool
+	tokens []jsToken
+}
+
+func (s *jsScanner) peekAt(offset int) byte {
+	if s.i+offset < len(s.src) {
+		return s.src[s.i+offset]
+	}
+	return 0
+}
+
+func (s *jsScanner) emit(kind jsTokenKind, text string, start int) {
+	s.tokens = append(s.tokens, jsToken{kind: kind, text: text, start: start, end: s.i})
+}
+
+func isJSIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c == '\\' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+func isJSIdentPart(c byte) bool {
+	return isJSIdentStart(c) || '0' <= c && c <= '9'
+}
+
+// Return wether the last token ends an expression, in which case "/" and "<" are operators.
+func (s *jsScanner) afterExpression() bool {
+	if len(s.tokens) == 0 {
+		return false
+	}
+	last := s.tokens[len(s.tokens)-1]
+	switch last.kind {
+	case jsIdent:
+		return !slices.Contains(jsKeywordsBeforeExpr, last.text)
+	case jsPunct:
+		return last.text == ")" || last.text == "]" || last.text == "}"
+	default:
+		return true
+	}
+}
+
+// Skip whitespace and comments.
+func (s *jsScanner) skipSpace() {
+	for s.i < len(s.src) {
+		switch c := s.src[s.i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			s.i++
+		case c == '/' && s.peekAt(1) == '/':
+			for s.i < len(s.src) && s.src[s.i] != '\n' {
+				s.i++
+			}
+		case c == '/' && s.peekAt(1) == '*':
+			end := strings.Index(s.src[s.i+2:], "*/")
+			if end < 0 {
+				s.i = len(s.src)
+			} else {
+				s.i += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Scan code until the end of the input, or until an unmatched closing brace if [inBraces] is true.
+func (s *jsScanner) scanCode(inBraces bool) {
+	depth := 0
+	for {
+		s.skipSpace()
+		if s.i >= len(s.src) {
+			return
+		}
+		start := s.i
+		switch c := s.src[s.i]; {
+		case c == '"' || c == '\'':
+			s.emit(jsString, s.scanString(c), start)
+		case c == '`':
+			value, substituted := s.scanTemplate()
+			s.emit(jsTemplate, value, start)
+			s.tokens[len(s.tokens)-1].substituted = substituted
+		case isJSIdentStart(c):
+			for s.i < len(s.src) && isJSIdentPart(s.src[s.i]) {
+				s.i++
+			}
+			s.emit(jsIdent, s.src[start:s.i], start)
+		case '0' <= c && c <= '9' || c == '.' && '0' <= s.peekAt(1) && s.peekAt(1) <= '9':
+			for s.i < len(s.src) && (isJSIdentPart(s.src[s.i]) || s.src[s.i] == '.') {
+				s.i++
+			}
+			s.emit(jsNumber, s.src[start:s.i], start)
+		case c == '/' && !s.afterExpression():
+			s.scanRegex()
+			s.emit(jsRegex, s.src[start:s.i], start)
+		case c == '<' && s.jsx && !s.afterExpression() && s.looksLikeJSX():
+			s.scanElement()
+			s.emit(jsElement, "", start)
+		case c == '{':
+			s.i++
+			depth++
+			s.emit(jsPunct, "{", start)
+		case c == '}':
+			s.i++
+			if depth == 0 && inBraces {
+				return
+			}
+			depth--
+			s.emit(jsPunct, "}", start)
+		default:
+			s.i++
+			s.emit(jsPunct, string(c), start)
+		}
+	}
+}
+
+// Scan a quoted string and return its value.
+func (s *jsScanner) scanString(quote byte) string {
+	start := s.i
+	s.i++
+	for s.i < len(s.src) && s.src[s.i] != quote && s.src[s.i] != '\n' {
+		if s.src[s.i] == '\\' {
+			s.i++
+		}
+		s.i++
+	}
+	s.i = min(s.i+1, len(s.src))
+	return unescapeJS(s.src[start+1 : max(start+1, s.i-1)])
+}
+
+// Scan a template literal, including the code of its substitutions. Return its value if it has no substitutions.
+func (s *jsScanner) scanTemplate() (string, bool) {
+	start := s.i
+	s.i++
+	substituted := false
+	for s.i < len(s.src) && s.src[s.i] != '`' {
+		switch {
+		case s.src[s.i] == '\\':
+			s.i += 2
+		case s.src[s.i] == '$' && s.peekAt(1) == '{':
+			s.i += 2
+			substituted = true
+			s.scanCode(true)
+		default:
+			s.i++
+		}
+	}
+	s.i = min(s.i+1, len(s.src))
+	if substituted {
+		return "", true
+	}
+	return unescapeJS(s.src[start+1 : max(start+1, s.i-1)]), false
+}
+
+func (s *jsScanner) scanRegex() {
+	s.i++
+	inClass := false
+	for s.i < len(s.src) && s.src[s.i] != '\n' {
+		c := s.src[s.i]
+		s.i++
+		if c == '\\' {
+			s.i++
+		} else if c == '[' {
+			inClass = true
+		} else if c == ']' {
+			inClass = false
+		} else if c == '/' && !inClass {
+			break
+		}
+	}
+	for s.i < len(s.src) && isJSIdentPart(s.src[s.i]) {
+		s.i++
+	}
+}
+
+// Return wether the "<" at the current position opens a JSX element rather than type parameters.
+func (s *jsScanner) looksLikeJSX() bool {
+	j := s.i + 1
+	if j < len(s.src) && s.src[j] == '>' {
+		return true
+	}
+	if j >= len(s.src) || !isJSIdentStart(s.src[j]) {
+		return false
+	}
+	for j < len(s.src) && (isJSIdentPart(s.src[j]) || s.src[j] == '.' || s.src[j] == ':' || s.src[j] == '-') {
+		j++
+	}
+	rest := strings.TrimLeft(s.src[j:], " \t\r\n")
+	// generic arrow functions in TSX are written <T,>() => ... or <T extends U>() => ...
+	return !strings.HasPrefix(rest, ",") && !strings.HasPrefix(rest, "extends ")
+}
+
+// Scan a JSX element or fragment, tokenizing the expressions it contains.
+func (s *jsScanner) scanElement() {
+	s.i++ // <
+	for s.i < len(s.src) && (isJSIdentPart(s.src[s.i]) || s.src[s.i] == '.' || s.src[s.i] == ':' || s.src[s.i] == '-') {
+		s.i++
+	}
+
+	// attributes
+	for s.i < len(s.src) {
+		switch c := s.src[s.i]; {
+		case c == '/' && s.peekAt(1) == '>':
+			s.i += 2
+			return
+		case c == '>':
+			s.i++
+			s.scanChildren()
+			return
+		case c == '{':
+			s.i++
+			s.scanCode(true)
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s.src[s.i+1:], c)
+			if end < 0 {
+				s.i = len(s.src)
+			} else {
+				s.i += end + 2
+			}
+		default:
+			s.i++
+		}
+	}
+}
+
+// Scan the children of a JSX element up to its closing tag.
+func (s *jsScanner) scanChildren() {
+	for s.i < len(s.src) {
+		switch c := s.src[s.i]; {
+		case c == '<' && s.peekAt(1) == '/':
+			end := strings.IndexByte(s.src[s.i:], '>')
+			if end < 0 {
+				s.i = len(s.src)
+			} else {
+				s.i += end + 1
+			}
+			return
+		case c == '<':
+			s.scanElement()
+		case c == '{':
+			s.i++
+			s.scanCode(true)
+		default:
+			s.i++
+		}
+	}
+}
+
+// Replace the escape sequences of a string literal.
+func unescapeJS(raw string) string {
+	if !strings.Contains(raw, "\\") {
+		return raw
+	}
+	var value strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i+1 >= len(raw) {
+			value.WriteByte(raw[i])
+			continue
+		}
+		i++
+		switch c := raw[i]; c {
+		case 'n':
+			value.WriteByte('\n')
+		case 't':
+			value.WriteByte('\t')
+		case 'r':
+			value.WriteByte('\r')
+		case 'b':
+			value.WriteByte('\b')
+		case 'f':
+			value.WriteByte('\f')
+		case 'v':
+			value.WriteByte('\v')
+		case '0':
+			value.WriteByte(0)
+		case '\n':
+			// line continuation
+		case 'x':
+			if code, err := strconv.ParseUint(raw[i+1:min(i+3, len(raw))], 16, 8); err == nil {
+				value.WriteRune(rune(code))
+				i += 2
+			} else {
+				value.WriteByte(c)
+			}
+		case 'u':
+			digits := raw[i+1 : min(i+5, len(raw))]
+			if strings.HasPrefix(raw[i+1:], "{") {
+				digits, _, _ = strings.Cut(raw[i+2:], "}")
+				i += 2
+			}
+			if code, err := strconv.ParseUint(digits, 16, 32); err == nil {
+				value.WriteRune(rune(code))
+				i += len(digits)
+			} else {
+				value.WriteByte(c)
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return value.String()
+}
+
+// Return wether JSX may appear in a file with the given extension.
+func allowsJSX(ext string) bool {
+	return ext != ".ts" && ext != ".mts" && ext != ".cts"
+}
+
+// Find the calls to [translateFn] in JavaScript or TypeScript code. Keys can be string literals or template literals
+// without substitutions.
+func JavaScript(path string, src []byte, translateFn string, jsx bool) []Call {
+	s := &jsScanner{src: string(src), jsx: jsx}
+	s.scanCode(false)
+	lines := newLineTable(s.src)
+
+	var calls []Call
+	for i, tok := range s.tokens {
+		if tok.kind != jsIdent || tok.text != translateFn || i+1 >= len(s.tokens) || s.tokens[i+1].text != "(" {
+			continue
+		}
+		if i > 0 && s.tokens[i-1].kind == jsIdent && s.tokens[i-1].text == "function" {
+			// this is the definition of the translate function
+			continue
+		}
+
+		line, column := lines.position(s.src, tok.start)
+		call := Call{Path: path, Line: line, Column: column, Dynamic: true}
+		if i+2 < len(s.tokens) {
+			arg := s.tokens[i+2]
+			call.Start, call.End = arg.start, arg.end
+			isLiteral := arg.kind == jsString || arg.kind == jsTemplate && !arg.substituted
+			isAlone := i+3 < len(s.tokens) && (s.tokens[i+3].text == "," || s.tokens[i+3].text == ")")
+			if isLiteral && isAlone {
+				call.Key = arg.text
+				call.Dynamic = false
+			}
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// The offsets at which each line of a file starts.
+type lineTable []int
+
+func newLineTable(src string) lineTable {
+	table := lineTable{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			table = append(table, i+1)
+		}
+	}
+	return table
+}
+
+// Return the line and column (in runes) of an offset in [src], starting from 1.
+func (table lineTable) position(src string, offset int) (int, int) {
+	line, found := slices.BinarySearch(table, offset)
+	if !found {
+		line--
+	}
+	return line + 1, utf8.RuneCountInString(src[table[line]:offset]) + 1
+}
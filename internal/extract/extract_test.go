@@ -0,0 +1,110 @@
+package extract_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/louisdevie/elizalina2/internal/extract"
+)
+
+func TestExtractGo(t *testing.T) {
+	calls, err := extract.File("testdata/main.go", extract.Options{GoTranslateFn: "T"})
+	if err != nil {
+		t.Fatalf("error extracting messages from main.go: %s", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 calls but got %d: %v", len(calls), calls)
+	}
+
+	if calls[0].Key != "greeting" || calls[0].Position() != "testdata/main.go:6:10" {
+		t.Fatalf("expected the first call to be greeting at testdata/main.go:6:10 but got %v at %s",
+			calls[0].Key, calls[0].Position())
+	}
+	if calls[1].Key != "inbox.count" {
+		t.Fatalf("expected raw strings to be extracted but got %v", calls[1])
+	}
+	if !calls[2].Dynamic || calls[2].Position() != "testdata/main.go:9:10" {
+		t.Fatalf("expected the third call to be dynamic but got %v", calls[2])
+	}
+
+	keys := extract.Keys(calls)
+	if len(keys) != 2 || keys[0] != "greeting" || keys[1] != "inbox.count" {
+		t.Fatalf("expected keys to be [greeting inbox.count] but got %v", keys)
+	}
+}
+
+func TestCallOffsets(t *testing.T) {
+	src := []byte("package p\n\nvar x = T(\"key\")\n")
+	calls, err := extract.Go("p.go", src, "T")
+	if err != nil {
+		t.Fatalf("error extracting messages: %s", err)
+	}
+	if len(calls) != 1 || string(src[calls[0].Start:calls[0].End]) != `"key"` {
+		t.Fatalf("expected the offsets to cover the key literal but got %v", calls)
+	}
+}
+
+func TestExtractJavaScript(t *testing.T) {
+	calls, err := extract.File("testdata/app.tsx", extract.Options{JSTranslateFn: "__"})
+	if err != nil {
+		t.Fatalf("error extracting messages from app.tsx: %s", err)
+	}
+	if len(calls) != 6 {
+		t.Fatalf("expected 6 calls but got %d: %v", len(calls), calls)
+	}
+
+	keys := extract.Keys(calls)
+	expected := []string{"inbox.title", "inbox.count", "inbox.empty", "nested"}
+	if !slices.Equal(keys, expected) {
+		t.Fatalf("expected keys to be %v but got %v", expected, keys)
+	}
+	if calls[0].Position() != "testdata/app.tsx:9:15" {
+		t.Fatalf("expected the first call to be at testdata/app.tsx:9:15 but got %s", calls[0].Position())
+	}
+	if !calls[4].Dynamic || !calls[5].Dynamic || calls[5].Position() != "testdata/app.tsx:18:1" {
+		t.Fatalf("expected the last two calls to be dynamic but got %v", calls[4:])
+	}
+}
+
+func TestJavaScriptOffsets(t *testing.T) {
+	src := []byte("const x = i18n.__('key', 1) / 2;\n")
+	calls := extract.JavaScript("p.js", src, "__", true)
+	if len(calls) != 1 || string(src[calls[0].Start:calls[0].End]) != `'key'` {
+		t.Fatalf("expected the offsets to cover the key literal but got %v", calls)
+	}
+}
+
+func TestColumnsInRunes(t *testing.T) {
+	src := []byte("package p\n\nvar x, y = \"ééééé\", T(\"key\")\n")
+	calls, err := extract.Go("p.go", src, "T")
+	if err != nil {
+		t.Fatalf("error extracting messages: %s", err)
+	}
+	if len(calls) != 1 || calls[0].Position() != "p.go:3:21" {
+		t.Fatalf("expected the Go call to be at p.go:3:21 but got %v", calls)
+	}
+
+	calls = extract.JavaScript("p.js", []byte("const x = 'ééééé', y = __('key');\n"), "__", true)
+	if len(calls) != 1 || calls[0].Position() != "p.js:1:24" {
+		t.Fatalf("expected the JavaScript call to be at p.js:1:24 but got %v", calls)
+	}
+}
+
+func TestSplitPaths(t *testing.T) {
+	calls := []extract.Call{
+		{Path: "inbox.go", Key: "inbox.count"},
+		{Path: "main.go", Key: "greeting"},
+		{Path: "mixed.go", Key: "inbox.title"},
+		{Path: "mixed.go", Key: "greeting"},
+		{Path: "inbox.go", Key: "inbox.title"},
+		{Path: "dynamic.go", Key: "inbox.count"},
+		{Path: "dynamic.go", Dynamic: true},
+	}
+	only, mixed := extract.SplitPaths(calls, "inbox.")
+	if !slices.Equal(only, []string{"inbox.go"}) {
+		t.Fatalf("expected only inbox.go to use just the prefix but got %v", only)
+	}
+	if !slices.Equal(mixed, []string{"mixed.go", "dynamic.go"}) {
+		t.Fatalf("expected mixed.go and dynamic.go to be mixed but got %v", mixed)
+	}
+}
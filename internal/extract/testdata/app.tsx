@@ -0,0 +1,18 @@
+import { __ } from "./i18n";
+
+// __("commented.out")
+const pattern = /__\("not.a.call"\)/g;
+const ratio = width / height / 2;
+
+export function Inbox<T,>({ count }: { count: number }) {
+	return (
+		<div title={__("inbox.title")}>
+			It's {count > 0 ? __(`inbox.count`, count) : __('inbox.empty')}
+			<span>{`${__("nested")} /* not a comment */`}</span>
+		</div>
+	);
+}
+
+const key = "dynamic";
+__(key);
+__(`inbox.${key}`);
@@ -0,0 +1,12 @@
+package main
+
+import "example.com/app/i18n"
+
+func main() {
+	println(i18n.T("greeting", "Ana"))
+	println(T(`inbox.count`, 3))
+	key := "dynamic"
+	println(i18n.T(key))
+	println(i18n.T("greeting", "Bob"))
+	println(Translate("not.a.call"))
+}
@@ -0,0 +1,224 @@
+// Matching of slash-separated paths against glob patterns.
+//
+// In a pattern, * matches any sequence of characters except slashes, ? matches a single character except a slash and
+// [...] matches a character class, which is negated when it starts with ! or ^. A ** segment matches any number of
+// directories, and ** inside a segment matches any sequence of characters including slashes. A backslash escapes the
+// next character. Patterns starting with ! are negated and exclude the paths matched by the patterns before them.
+package glob
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// A compiled glob pattern.
+type Pattern struct {
+	raw     string
+	negated bool
+	base    string
+	re      *regexp.Regexp
+}
+
+// Compile a pattern. Leading ./ and redundant slashes are ignored.
+func Compile(pattern string) (*Pattern, error) {
+	p := &Pattern{raw: pattern}
+	if strings.HasPrefix(pattern, "!") {
+		p.negated = true
+		pattern = pattern[1:]
+	}
+	pattern = path.Clean(pattern)
+	if pattern == "." {
+		pattern = "**"
+	}
+
+	var (
+		expr      strings.Builder
+		segments  = strings.Split(pattern, "/")
+		baseEnded = false
+		base      []string
+	)
+	expr.WriteString("^")
+	for i, segment := range segments {
+		if !baseEnded && !hasMeta(segment) {
+			base = append(base, segment)
+		} else {
+			baseEnded = true
+		}
+
+		if segment == "**" {
+			switch {
+			case len(segments) == 1:
+				expr.WriteString(".*")
+			case i == len(segments)-1:
+				expr.WriteString("(?:/.*)?")
+			case i == 0:
+				expr.WriteString("(?:.*/)?")
+			default:
+				expr.WriteString("/(?:.*/)?")
+			}
+			continue
+		}
+		if i > 0 && segments[i-1] != "**" {
+			expr.WriteString("/")
+		}
+		if err := translateSegment(&expr, segment); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p.raw, err)
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		// a character class can still be invalid, like [z-a]
+		return nil, fmt.Errorf("invalid pattern %q: %w", p.raw, err)
+	}
+	p.re = re
+	p.base = strings.Join(base, "/")
+	if p.base == "" {
+		p.base = "."
+	}
+	return p, nil
+}
+
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
+
+// Write the regular expression matching a segment of a pattern.
+func translateSegment(expr *strings.Builder, segment string) error {
+	for i := 0; i < len(segment); i++ {
+		switch c := segment[i]; c {
+		case '*':
+			if i+1 < len(segment) && segment[i+1] == '*' {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(segment[i+1:], ']')
+			if end == 0 {
+				// a closing bracket right after the opening one is part of the class
+				end = strings.IndexByte(segment[i+2:], ']') + 1
+			}
+			if end <= 0 {
+				return fmt.Errorf("unterminated character class")
+			}
+			class := segment[i+1 : i+1+end]
+			expr.WriteString("[")
+			if class[0] == '!' || class[0] == '^' {
+				expr.WriteString("^/")
+				class = class[1:]
+			}
+			expr.WriteString(strings.ReplaceAll(class, `\`, `\\`))
+			expr.WriteString("]")
+			i += end + 1
+		case '\\':
+			if i+1 >= len(segment) {
+				return fmt.Errorf("trailing backslash")
+			}
+			i++
+			expr.WriteString(regexp.QuoteMeta(segment[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(segment[i : i+1]))
+		}
+	}
+	return nil
+}
+
+// The pattern as it was written.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// Wether the pattern starts with !.
+func (p *Pattern) Negated() bool {
+	return p.negated
+}
+
+// The leading directories of the pattern that contain no wildcard, or "." if there are none. Every path matched by the
+// pattern is inside of this directory.
+func (p *Pattern) Base() string {
+	return p.base
+}
+
+// Return wether a slash-separated path matches the pattern, ignoring negation.
+func (p *Pattern) Match(name string) bool {
+	return p.re.MatchString(path.Clean(name))
+}
+
+// An ordered list of patterns.
+type Set []*Pattern
+
+// Compile a list of patterns.
+func NewSet(patterns []string) (Set, error) {
+	set := make(Set, 0, len(patterns))
+	for _, raw := range patterns {
+		p, err := Compile(raw)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, p)
+	}
+	return set, nil
+}
+
+// Return wether a path is included by the set. A path is included if the last pattern matching it or one of its parent
+// directories is not negated.
+func (set Set) Match(name string) bool {
+	name = path.Clean(name)
+	included := false
+	for _, p := range set {
+		if p.negated == included && p.matchSelfOrParent(name) {
+			included = !p.negated
+		}
+	}
+	return included
+}
+
+func (p *Pattern) matchSelfOrParent(name string) bool {
+	for {
+		if p.re.MatchString(name) {
+			return true
+		}
+		parent := path.Dir(name)
+		if parent == name || parent == "." || parent == "/" || strings.HasSuffix(parent, "..") {
+			return false
+		}
+		name = parent
+	}
+}
+
+// Return wether some patterns of the set are negated.
+func (set Set) HasNegations() bool {
+	for _, p := range set {
+		if p.negated {
+			return true
+		}
+	}
+	return false
+}
+
+// The base directories of the patterns that are not negated, without duplicates.
+func (set Set) Bases() []string {
+	var bases []string
+	for _, p := range set {
+		if !p.negated && !containsParent(bases, p.base) {
+			bases = append(bases, p.base)
+		}
+	}
+	return bases
+}
+
+func containsParent(dirs []string, name string) bool {
+	for _, dir := range dirs {
+		if dir == "." && !strings.HasPrefix(name, "..") || dir == name || strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
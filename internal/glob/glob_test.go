@@ -0,0 +1,94 @@
+package glob_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/louisdevie/elizalina2/internal/glob"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"src/*.go", "src/main.go", true},
+		{"src/*.go", "src/cmd/main.go", false},
+		{"src/**/*.go", "src/main.go", true},
+		{"src/**/*.go", "src/cmd/app/main.go", true},
+		{"**/*.ts", "index.ts", true},
+		{"src/**", "src/a/b", true},
+		{"src/**.jsx", "src/ui/app.jsx", true},
+		{"src/**.jsx", "lib/app.jsx", false},
+		{"./src/?.js", "src/a.js", true},
+		{"src/?.js", "src/ab.js", false},
+		{"[a-c]*.go", "b.go", true},
+		{"[!a-c]*.go", "b.go", false},
+		{"[^a-c]*.go", "d.go", true},
+		{`\*.go`, "*.go", true},
+		{`\*.go`, "a.go", false},
+		{"../shared/*.ts", "../shared/index.ts", true},
+	}
+	for _, c := range cases {
+		p, err := glob.Compile(c.pattern)
+		if err != nil {
+			t.Fatalf("error compiling %q: %s", c.pattern, err)
+		}
+		if p.Match(c.name) != c.match {
+			t.Errorf("expected %q matching %q to be %v", c.pattern, c.name, c.match)
+		}
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	if _, err := glob.Compile("src/[a-z.go"); err == nil {
+		t.Fatal("expected an unterminated class to be an error")
+	}
+	if _, err := glob.Compile("src/[z-a].go"); err == nil {
+		t.Fatal("expected an invalid class range to be an error")
+	}
+	if _, err := glob.Compile("src/[[:alpha:]].go"); err == nil {
+		t.Fatal("expected an invalid class to be an error")
+	}
+}
+
+func TestBase(t *testing.T) {
+	cases := map[string]string{
+		"src/ui/*.ts": "src/ui",
+		"src/app.ts":  "src/app.ts",
+		"**/*.go":     ".",
+		"!lib/**":     "lib",
+	}
+	for pattern, expected := range cases {
+		p, err := glob.Compile(pattern)
+		if err != nil {
+			t.Fatalf("error compiling %q: %s", pattern, err)
+		}
+		if p.Base() != expected {
+			t.Errorf("expected the base of %q to be %q but got %q", pattern, expected, p.Base())
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	set, err := glob.NewSet([]string{"src", "!src/generated", "src/generated/keep.go"})
+	if err != nil {
+		t.Fatalf("error compiling the set: %s", err)
+	}
+	if !set.Match("src/main.go") {
+		t.Error("expected files inside of a matching directory to be included")
+	}
+	if set.Match("src/generated/gen.go") {
+		t.Error("expected negated patterns to exclude files")
+	}
+	if !set.Match("src/generated/keep.go") {
+		t.Error("expected later patterns to include files back")
+	}
+	if set.Match("lib/main.go") {
+		t.Error("expected files matching no pattern to be excluded")
+	}
+	if bases := set.Bases(); !slices.Equal(bases, []string{"src"}) {
+		t.Errorf("expected the bases to be [src] but got %v", bases)
+	}
+}
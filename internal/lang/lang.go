@@ -0,0 +1,360 @@
+// Translation files and their syntax tree.
+//
+// Translations are stored in a directory as one file per locale and prefix, named either
+// "<locale>.elz" (for messages without a prefix) or "<prefix>.<locale>.elz". Each file is a
+// list of messages, one key followed by its text:
+//
+//	# Comments start with a hash sign and are attached to the message below them.
+//	greeting    Hello, {name}!
+//	inbox.count You have {count ? 0 => no messages | 1 => one message | * => {count} messages}.
+//	inbox.empty
+//		Lines that start with whitespace continue the previous message.
+//		Line breaks are read as a single space.
+//
+// Placeholders are written {name} or {name:type}, where the type is one of string, int,
+// float or bool. Conditionals are written {name ? selector => text | ...}, and must have a
+// default case "*" unless they select on both true and false. The characters \ { } | can be
+// escaped with a backslash, as well as \n (new line), \t (tab) and "\ " (a significant space).
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+// The extension of translation files.
+const Extension = ".elz"
+
+// A position in a translation file. Lines and columns start at 1, and columns are counted in runes.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+func (pos Pos) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
+// A parsed translation file.
+type File struct {
+	Path     string
+	Locale   string
+	Prefix   string
+	Header   []*Comment
+	Messages []*Message
+	Trailer  []*Comment
+}
+
+// Return the message with the given key, or <nil> if there is none.
+func (file *File) Lookup(key string) *Message {
+	for _, msg := range file.Messages {
+		if msg.Key == key {
+			return msg
+		}
+	}
+	return nil
+}
+
+// Append an empty message with the given key, or return the existing one.
+func (file *File) Add(key string) *Message {
+	if msg := file.Lookup(key); msg != nil {
+		return msg
+	}
+	msg := &Message{Key: key}
+	file.Messages = append(file.Messages, msg)
+	return msg
+}
+
+// Remove the message with the given key. Return wether there was such a message.
+func (file *File) Remove(key string) bool {
+	for i, msg := range file.Messages {
+		if msg.Key == key {
+			file.Messages = slices.Delete(file.Messages, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// A line comment, without its leading hash sign.
+type Comment struct {
+	Text        string
+	Pos         Pos
+	BlankBefore bool
+}
+
+// A translated message.
+type Message struct {
+	Key         string
+	Pos         Pos
+	Comments    []*Comment
+	Text        Text
+	BlankBefore bool
+}
+
+// Return the placeholders used by the message in order of first appearance, with their types resolved.
+func (msg *Message) Params() []Param {
+	params := msg.DeclaredParams()
+	for i := range params {
+		if params[i].Type == "" {
+			params[i].Type = String
+		}
+	}
+	return params
+}
+
+// Return the placeholders used by the message in order of first appearance. Their type is empty when it is neither
+// specified nor inferred from the selectors of a conditional.
+func (msg *Message) DeclaredParams() []Param {
+	var params []Param
+	msg.Text.walkParams(func(name string, typ Type) {
+		for i := range params {
+			if params[i].Name == name {
+				if params[i].Type == "" {
+					params[i].Type = typ
+				}
+				return
+			}
+		}
+		params = append(params, Param{Name: name, Type: typ})
+	})
+	return params
+}
+
+// Return wether the message has no text.
+func (msg *Message) IsEmpty() bool {
+	return len(msg.Text) == 0
+}
+
+// A message parameter.
+type Param struct {
+	Name string
+	Type Type
+}
+
+// The type of a placeholder.
+type Type string
+
+const (
+	String Type = "string"
+	Int    Type = "int"
+	Float  Type = "float"
+	Bool   Type = "bool"
+)
+
+func isValidType(typ Type) bool {
+	return typ == String || typ == Int || typ == Float || typ == Bool
+}
+
+// The content of a message: a sequence of *Literal, *Placeholder and *Conditional segments.
+type Text []Segment
+
+type Segment interface {
+	Position() Pos
+}
+
+func (text Text) walkParams(visit func(name string, typ Type)) {
+	for _, seg := range text {
+		switch seg := seg.(type) {
+		case *Placeholder:
+			visit(seg.Name, seg.Type)
+		case *Conditional:
+			visit(seg.Name, seg.ResolvedType())
+			for _, c := range seg.Cases {
+				c.Text.walkParams(visit)
+			}
+		}
+	}
+}
+
+// Raw text, with escape sequences already replaced.
+type Literal struct {
+	Value string
+	Pos   Pos
+}
+
+func (lit *Literal) Position() Pos { return lit.Pos }
+
+// A placeholder such as {name} or {count:int}. The type is empty if it was not specified.
+type Placeholder struct {
+	Name string
+	Type Type
+	Pos  Pos
+}
+
+func (ph *Placeholder) Position() Pos { return ph.Pos }
+
+// A conditional such as {count ? 1 => one | * => many}.
+type Conditional struct {
+	Name  string
+	Type  Type
+	Cases []*Case
+	Pos   Pos
+}
+
+func (cond *Conditional) Position() Pos { return cond.Pos }
+
+// Return the explicit type of the conditional or infer it from its selectors.
+func (cond *Conditional) ResolvedType() Type {
+	if cond.Type != "" {
+		return cond.Type
+	}
+	for _, c := range cond.Cases {
+		switch c.Selector.Kind {
+		case NumberSelector:
+			return Int
+		case BoolSelector:
+			return Bool
+		case WordSelector:
+			return String
+		}
+	}
+	return ""
+}
+
+// A branch of a conditional.
+type Case struct {
+	Selector Selector
+	Text     Text
+}
+
+type SelectorKind uint8
+
+const (
+	DefaultSelector SelectorKind = iota
+	NumberSelector
+	BoolSelector
+	WordSelector
+)
+
+// The value a conditional case matches. The value of a default selector is "*".
+type Selector struct {
+	Kind  SelectorKind
+	Value string
+	Pos   Pos
+}
+
+// Return the name of the file containing the messages of [prefix] in [locale].
+func FileName(prefix string, locale string) string {
+	if prefix == project.NoPrefix {
+		return locale + Extension
+	} else {
+		return prefix + "." + locale + Extension
+	}
+}
+
+// Return wether [locale] can be used to name translation files. Locales are made of letters, digits, hyphens and
+// underscores and start with a letter, like "en" or "fr-CA".
+func IsValidLocale(locale string) bool {
+	for i, c := range locale {
+		isLetter := 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+		if !isLetter && (i == 0 || !('0' <= c && c <= '9' || c == '-' || c == '_')) {
+			return false
+		}
+	}
+	return locale != ""
+}
+
+// Extract the prefix and locale from the name of a translation file.
+func ParseFileName(path string) (prefix string, locale string, err error) {
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, Extension) {
+		return "", "", fmt.Errorf("%s: not a translation file", path)
+	}
+	parts := strings.Split(strings.TrimSuffix(name, Extension), ".")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return project.NoPrefix, parts[0], nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("%s: translation files should be named <locale>%s or <prefix>.<locale>%s",
+			path, Extension, Extension)
+	}
+}
+
+// Read and parse a translation file.
+func ParseFile(path string) (*File, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	prefix, locale, err := ParseFileName(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Parse(path, src)
+	file.Prefix = prefix
+	file.Locale = locale
+	return file, err
+}
+
+// All the translation files of a project.
+type Catalog struct {
+	Dir   string
+	Files []*File
+}
+
+// Parse every translation file in [dir]. Files that could be read are returned even if some of them are invalid.
+func LoadDir(dir string) (*Catalog, error) {
+	catalog := &Catalog{Dir: dir}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return catalog, err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != Extension {
+			continue
+		}
+		file, err := ParseFile(filepath.Join(dir, entry.Name()))
+		if file != nil {
+			catalog.Files = append(catalog.Files, file)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return catalog, errors.Join(errs...)
+}
+
+// Return the file for [prefix] in [locale], or <nil> if there is none.
+func (catalog *Catalog) Get(prefix string, locale string) *File {
+	for _, file := range catalog.Files {
+		if file.Prefix == prefix && file.Locale == locale {
+			return file
+		}
+	}
+	return nil
+}
+
+// Return the locales present in the catalog, sorted.
+func (catalog *Catalog) Locales() []string {
+	var locales []string
+	for _, file := range catalog.Files {
+		if !slices.Contains(locales, file.Locale) {
+			locales = append(locales, file.Locale)
+		}
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Return the prefixes present in the catalog, sorted.
+func (catalog *Catalog) Prefixes() []string {
+	var prefixes []string
+	for _, file := range catalog.Files {
+		if !slices.Contains(prefixes, file.Prefix) {
+			prefixes = append(prefixes, file.Prefix)
+		}
+	}
+	slices.Sort(prefixes)
+	return prefixes
+}
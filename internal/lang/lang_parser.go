@@ -0,0 +1,554 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A syntax error in a translation file.
+type SyntaxError struct {
+	Path string
+	Pos  Pos
+	Msg  string
+}
+
+func (err *SyntaxError) Error() string {
+	if err.Path == "" {
+		return fmt.Sprintf("%s: %s", err.Pos, err.Msg)
+	}
+	return fmt.Sprintf("%s:%s: %s", err.Path, err.Pos, err.Msg)
+}
+
+// All the syntax errors found in a file.
+type ErrorList []*SyntaxError
+
+func (list ErrorList) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", list[0], len(list)-1)
+	}
+}
+
+func (list ErrorList) Unwrap() []error {
+	errs := make([]error, len(list))
+	for i, err := range list {
+		errs[i] = err
+	}
+	return errs
+}
+
+// A piece of message text and the position of its first rune.
+type fragment struct {
+	text string
+	pos  Pos
+}
+
+type parser struct {
+	path string
+	errs ErrorList
+}
+
+func (p *parser) error(pos Pos, format string, args ...any) {
+	p.errs = append(p.errs, &SyntaxError{Path: p.path, Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// Parse the content of a translation file. [path] is only used in error messages. The file is returned even if it
+// contains errors, in which case the error is an ErrorList.
+func Parse(path string, src []byte) (*File, error) {
+	p := &parser{path: path}
+	file := &File{Path: path}
+
+	var (
+		current     *Message
+		fragments   []fragment
+		comments    []*Comment
+		blankBefore bool
+		keys        = make(map[string]Pos)
+	)
+	flush := func() {
+		if current != nil {
+			current.Text = p.parseText(fragments)
+			p.checkTypes(current.Text, make(map[string]Type))
+			current = nil
+			fragments = nil
+		}
+	}
+
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		lineNo := i + 1
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			if len(file.Messages) == 0 && len(comments) > 0 && file.Header == nil {
+				// the first comments of the file are kept at the top if they are separated by a blank line
+				file.Header = comments
+				comments = nil
+			}
+			blankBefore = true
+
+		case line[0] == '#':
+			flush()
+			comments = append(comments, &Comment{Text: line[1:], Pos: Pos{lineNo, 1}, BlankBefore: blankBefore})
+			blankBefore = false
+
+		case line[0] == ' ' || line[0] == '\t':
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			pos := Pos{lineNo, indent + 1}
+			if current == nil {
+				p.error(pos, "unexpected indentation (no message to continue)")
+			} else {
+				fragments = append(fragments, fragment{text: trimTrailingSpace(line[indent:]), pos: pos})
+			}
+
+		default:
+			flush()
+			keyLen := 0
+			for keyLen < len(line) && isKeyByte(line[keyLen], keyLen == 0) {
+				keyLen++
+			}
+			key, rest := line[:keyLen], line[keyLen:]
+			pos := Pos{lineNo, 1}
+			if keyLen == 0 {
+				p.error(pos, "expected a message key")
+				continue
+			}
+			if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+				r, _ := utf8.DecodeRuneInString(rest)
+				p.error(Pos{lineNo, utf8.RuneCountInString(key) + 1}, "unexpected character %q in message key", r)
+				continue
+			}
+			if first, exists := keys[key]; exists {
+				p.error(pos, "duplicate key %q (first defined at %s)", key, first)
+			} else {
+				keys[key] = pos
+			}
+
+			current = &Message{Key: key, Pos: pos, Comments: comments, BlankBefore: blankBefore}
+			file.Messages = append(file.Messages, current)
+			comments = nil
+			blankBefore = false
+
+			separator := len(rest) - len(strings.TrimLeft(rest, " \t"))
+			if text := trimTrailingSpace(rest[separator:]); text != "" {
+				fragments = append(fragments, fragment{text: text, pos: Pos{lineNo, keyLen + separator + 1}})
+			}
+		}
+	}
+	flush()
+	file.Trailer = comments
+
+	if len(p.errs) > 0 {
+		return file, p.errs
+	}
+	return file, nil
+}
+
+// Parse the text of a message outside of a file, such as one given on the command line. Line breaks are folded like
+// continuation lines. [path] is only used in error messages.
+func ParseText(path string, src string) (Text, error) {
+	p := &parser{path: path}
+	var fragments []fragment
+	for i, line := range strings.Split(src, "\n") {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if text := trimTrailingSpace(line[indent:]); text != "" {
+			fragments = append(fragments, fragment{text: text, pos: Pos{i + 1, indent + 1}})
+		}
+	}
+	text := p.parseText(fragments)
+	p.checkTypes(text, make(map[string]Type))
+	if len(p.errs) > 0 {
+		return text, p.errs
+	}
+	return text, nil
+}
+
+func isKeyByte(b byte, first bool) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', b == '_':
+		return true
+	case '0' <= b && b <= '9', b == '.', b == '-':
+		return !first
+	default:
+		return false
+	}
+}
+
+// Return wether [key] is a valid message key.
+func IsValidKey(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if !isKeyByte(key[i], i == 0) {
+			return false
+		}
+	}
+	return key != ""
+}
+
+// Remove whitespace at the end of a line, unless it is escaped.
+func trimTrailingSpace(line string) string {
+	trimmed := strings.TrimRight(line, " \t")
+	if trimmed == line {
+		return line
+	}
+	backslashes := len(trimmed) - len(strings.TrimRight(trimmed, "\\"))
+	if backslashes%2 == 1 {
+		return line[:len(trimmed)+1]
+	}
+	return trimmed
+}
+
+// Marks the end of the input in a textScanner.
+const eof = -1
+
+// Reads the text of a message rune by rune. Fragments are joined with new lines, which are read as spaces.
+type textScanner struct {
+	runes []rune
+	pos   []Pos
+	i     int
+	end   Pos
+}
+
+func newTextScanner(fragments []fragment) *textScanner {
+	s := &textScanner{}
+	for i, frag := range fragments {
+		if i > 0 {
+			s.runes = append(s.runes, '\n')
+			s.pos = append(s.pos, s.end)
+		}
+		pos := frag.pos
+		for _, r := range frag.text {
+			s.runes = append(s.runes, r)
+			s.pos = append(s.pos, pos)
+			pos.Column++
+		}
+		s.end = pos
+	}
+	return s
+}
+
+func (s *textScanner) peek() rune {
+	if s.i >= len(s.runes) {
+		return eof
+	}
+	return s.runes[s.i]
+}
+
+func (s *textScanner) next() rune {
+	r := s.peek()
+	if r != eof {
+		s.i++
+	}
+	return r
+}
+
+func (s *textScanner) position() Pos {
+	if s.i >= len(s.pos) {
+		return s.end
+	}
+	return s.pos[s.i]
+}
+
+func (s *textScanner) skipSpace() {
+	for unicode.IsSpace(s.peek()) {
+		s.i++
+	}
+}
+
+// Aborts parsing the current message after an error.
+type bailout struct{}
+
+func (p *parser) parseText(fragments []fragment) (text Text) {
+	s := newTextScanner(fragments)
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+		}
+	}()
+	return p.parseSegments(s, false)
+}
+
+func (p *parser) fail(pos Pos, format string, args ...any) {
+	p.error(pos, format, args...)
+	panic(bailout{})
+}
+
+// Builds a literal while keeping track of unescaped whitespace at its end.
+type literalBuilder struct {
+	value    strings.Builder
+	pos      Pos
+	trailing int
+}
+
+func (lb *literalBuilder) write(r rune, escaped bool) {
+	lb.value.WriteRune(r)
+	if !escaped && unicode.IsSpace(r) {
+		lb.trailing += utf8.RuneLen(r)
+	} else {
+		lb.trailing = 0
+	}
+}
+
+// Parse a sequence of segments. Inside conditional cases, stop before an unescaped "|" or "}".
+func (p *parser) parseSegments(s *textScanner, inCase bool) Text {
+	var (
+		text Text
+		lit  *literalBuilder
+	)
+	flushLiteral := func(trimEnd bool) {
+		if lit != nil {
+			value := lit.value.String()
+			if trimEnd {
+				value = value[:len(value)-lit.trailing]
+			}
+			if value != "" {
+				text = append(text, &Literal{Value: value, Pos: lit.pos})
+			}
+			lit = nil
+		}
+	}
+	write := func(pos Pos, r rune, escaped bool) {
+		if lit == nil {
+			lit = &literalBuilder{pos: pos}
+		}
+		lit.write(r, escaped)
+	}
+
+	if inCase {
+		s.skipSpace()
+	}
+	for {
+		pos := s.position()
+		switch r := s.peek(); {
+		case r == eof:
+			if inCase {
+				p.fail(pos, "unterminated conditional, expected \"}\"")
+			}
+			flushLiteral(false)
+			return text
+
+		case inCase && (r == '|' || r == '}'):
+			flushLiteral(true)
+			return text
+
+		case r == '}':
+			p.fail(pos, "unexpected \"}\" (use \"\\}\" to write a brace)")
+
+		case r == '{':
+			flushLiteral(false)
+			s.next()
+			text = append(text, p.parseExpr(s, pos))
+
+		case r == '\\':
+			s.next()
+			switch e := s.next(); e {
+			case '\\', '{', '}', '|', ' ':
+				write(pos, e, true)
+			case 'n':
+				write(pos, '\n', true)
+			case 't':
+				write(pos, '\t', true)
+			case eof, '\n':
+				p.fail(pos, "unterminated escape sequence")
+			default:
+				p.fail(pos, "unknown escape sequence \"\\%c\"", e)
+			}
+
+		case r == '\n':
+			s.next()
+			write(pos, ' ', false)
+
+		default:
+			s.next()
+			write(pos, r, false)
+		}
+	}
+}
+
+func isIdentRune(r rune, first bool) bool {
+	return r == '_' || unicode.IsLetter(r) || (!first && unicode.IsDigit(r))
+}
+
+func (p *parser) parseIdent(s *textScanner, what string) string {
+	var ident strings.Builder
+	pos := s.position()
+	for isIdentRune(s.peek(), ident.Len() == 0) {
+		ident.WriteRune(s.next())
+	}
+	if ident.Len() == 0 {
+		p.fail(pos, "expected %s, found %s", what, describeRune(s.peek()))
+	}
+	return ident.String()
+}
+
+func describeRune(r rune) string {
+	switch r {
+	case eof:
+		return "end of message"
+	case '\n':
+		return "end of line"
+	default:
+		return fmt.Sprintf("%q", r)
+	}
+}
+
+// Parse a placeholder or a conditional, after the opening brace.
+func (p *parser) parseExpr(s *textScanner, pos Pos) Segment {
+	s.skipSpace()
+	name := p.parseIdent(s, "a placeholder name")
+	s.skipSpace()
+
+	var typ Type
+	if s.peek() == ':' {
+		s.next()
+		s.skipSpace()
+		typePos := s.position()
+		typ = Type(p.parseIdent(s, "a type"))
+		if !isValidType(typ) {
+			p.fail(typePos, "unknown type %q (expected string, int, float or bool)", typ)
+		}
+		s.skipSpace()
+	}
+
+	closePos := s.position()
+	switch r := s.next(); r {
+	case '}':
+		return &Placeholder{Name: name, Type: typ, Pos: pos}
+	case '?':
+		cond := &Conditional{Name: name, Type: typ, Pos: pos}
+		for {
+			cond.Cases = append(cond.Cases, p.parseCase(s))
+			if s.next() == '}' {
+				break
+			}
+		}
+		p.checkConditional(cond)
+		return cond
+	default:
+		p.fail(closePos, "expected \"}\" or \"?\" after placeholder name, found %s", describeRune(r))
+		return nil
+	}
+}
+
+func (p *parser) parseCase(s *textScanner) *Case {
+	s.skipSpace()
+	sel := Selector{Pos: s.position()}
+	switch r := s.peek(); {
+	case r == '*':
+		s.next()
+		sel.Kind = DefaultSelector
+		sel.Value = "*"
+	case r == '-' || unicode.IsDigit(r):
+		var number strings.Builder
+		number.WriteRune(s.next())
+		for unicode.IsDigit(s.peek()) {
+			number.WriteRune(s.next())
+		}
+		sel.Kind = NumberSelector
+		sel.Value = number.String()
+		if sel.Value == "-" {
+			p.fail(sel.Pos, "expected a number after \"-\"")
+		}
+	case r == '"':
+		s.next()
+		var word strings.Builder
+		for {
+			c := s.next()
+			if c == '"' {
+				break
+			} else if c == '\\' && (s.peek() == '"' || s.peek() == '\\') {
+				c = s.next()
+			} else if c == eof {
+				p.fail(sel.Pos, "unterminated string")
+			}
+			word.WriteRune(c)
+		}
+		sel.Kind = WordSelector
+		sel.Value = word.String()
+	default:
+		sel.Value = p.parseIdent(s, "a selector")
+		if sel.Value == "true" || sel.Value == "false" {
+			sel.Kind = BoolSelector
+		} else {
+			sel.Kind = WordSelector
+		}
+	}
+
+	s.skipSpace()
+	arrowPos := s.position()
+	if s.next() != '=' || s.next() != '>' {
+		p.fail(arrowPos, "expected \"=>\" after selector")
+	}
+	return &Case{Selector: sel, Text: p.parseSegments(s, true)}
+}
+
+// Check that the selectors of a conditional are consistent with its type and cover every value.
+func (p *parser) checkConditional(cond *Conditional) {
+	var (
+		hasDefault bool
+		bools      = make(map[string]bool)
+		seen       = make(map[string]bool)
+		typ        = cond.ResolvedType()
+	)
+	for _, c := range cond.Cases {
+		sel := c.Selector
+		if seen[sel.Value] {
+			p.error(sel.Pos, "duplicate case %q", sel.Value)
+		}
+		seen[sel.Value] = true
+
+		switch sel.Kind {
+		case DefaultSelector:
+			hasDefault = true
+		case NumberSelector:
+			if typ != Int && typ != Float {
+				p.error(sel.Pos, "cannot select number %s on {%s} of type %s", sel.Value, cond.Name, typ)
+			}
+		case BoolSelector:
+			bools[sel.Value] = true
+			if typ != Bool && typ != String {
+				p.error(sel.Pos, "cannot select %s on {%s} of type %s", sel.Value, cond.Name, typ)
+			}
+		case WordSelector:
+			if typ != String {
+				p.error(sel.Pos, "cannot select %q on {%s} of type %s", sel.Value, cond.Name, typ)
+			}
+		}
+	}
+	if !hasDefault && !(typ == Bool && len(bools) == 2) {
+		p.error(cond.Pos, "conditional on {%s} has no default case \"*\"", cond.Name)
+	}
+}
+
+// Check that placeholders with the same name are not given different types.
+func (p *parser) checkTypes(text Text, types map[string]Type) {
+	check := func(name string, typ Type, pos Pos) {
+		if typ == "" {
+			return
+		}
+		if previous, ok := types[name]; ok && previous != typ {
+			p.error(pos, "{%s} is used both as %s and %s", name, previous, typ)
+		} else {
+			types[name] = typ
+		}
+	}
+	for _, seg := range text {
+		switch seg := seg.(type) {
+		case *Placeholder:
+			check(seg.Name, seg.Type, seg.Pos)
+		case *Conditional:
+			check(seg.Name, seg.ResolvedType(), seg.Pos)
+			for _, c := range seg.Cases {
+				p.checkTypes(c.Text, types)
+			}
+		}
+	}
+}
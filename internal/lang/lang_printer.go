@@ -0,0 +1,416 @@
+package lang
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+// The width of a tab when measuring lines.
+const tabWidth = 4
+
+// Options of the pretty-printer. See project.FormatConfig for their meaning.
+type FormatOptions struct {
+	PrintWidth           int
+	Inline               bool
+	Indent               int
+	UseTabs              bool
+	MinimumSpacing       int
+	MaximumSpacing       int
+	CollapseConditionals bool
+	SortMessages         project.MessageSort
+	// The keys in the order they appear in the sources, used when SortMessages is project.Source. Messages that are
+	// not in the list are placed after the others, in their original order.
+	SourceOrder []string
+}
+
+// Read the format options from the project configuration.
+func FormatOptionsFrom(cfg project.FormatConfig) (opts FormatOptions, err error) {
+	if opts.PrintWidth, err = cfg.PrintWidth(); err != nil {
+		return opts, err
+	}
+	if opts.Inline, err = cfg.Inline(); err != nil {
+		return opts, err
+	}
+	if opts.Indent, err = cfg.Indent(); err != nil {
+		return opts, err
+	}
+	if opts.UseTabs, err = cfg.UseTabs(); err != nil {
+		return opts, err
+	}
+	if opts.MinimumSpacing, err = cfg.MinimumSpacing(); err != nil {
+		return opts, err
+	}
+	if opts.MaximumSpacing, err = cfg.MaximumSpacing(); err != nil {
+		return opts, err
+	}
+	if opts.CollapseConditionals, err = cfg.CollapseConditionals(); err != nil {
+		return opts, err
+	}
+	opts.SortMessages, err = cfg.SortMessages()
+	return opts, err
+}
+
+// Print a translation file in its canonical form. The output only depends on the content of the file and the options.
+func Format(file *File, opts FormatOptions) []byte {
+	pr := &printer{opts: opts}
+
+	for _, c := range file.Header {
+		pr.comment(c, c.BlankBefore)
+	}
+	if len(file.Header) > 0 {
+		pr.blank()
+	}
+
+	messages := file.Messages
+	sorted := opts.SortMessages != project.Append
+	switch opts.SortMessages {
+	case project.Alphabetical:
+		messages = slices.Clone(messages)
+		slices.SortStableFunc(messages, func(a, b *Message) int { return strings.Compare(a.Key, b.Key) })
+	case project.Source:
+		order := make(map[string]int, len(opts.SourceOrder))
+		for i, key := range opts.SourceOrder {
+			if _, seen := order[key]; !seen {
+				order[key] = i
+			}
+		}
+		rank := func(msg *Message) int {
+			if i, ok := order[msg.Key]; ok {
+				return i
+			}
+			return len(opts.SourceOrder)
+		}
+		messages = slices.Clone(messages)
+		slices.SortStableFunc(messages, func(a, b *Message) int { return rank(a) - rank(b) })
+	}
+
+	// messages are aligned by blocks that are separated by blank lines
+	var block []*Message
+	for i, msg := range messages {
+		blankBefore := msg.BlankBefore
+		if len(msg.Comments) > 0 {
+			blankBefore = msg.Comments[0].BlankBefore
+		}
+		if i > 0 && blankBefore && !sorted {
+			pr.messages(block)
+			block = nil
+			pr.blank()
+		}
+		block = append(block, msg)
+	}
+	pr.messages(block)
+
+	for _, c := range file.Trailer {
+		pr.comment(c, c.BlankBefore)
+	}
+
+	return pr.out.Bytes()
+}
+
+type printer struct {
+	opts     FormatOptions
+	out      bytes.Buffer
+	lastLine bool
+}
+
+func (pr *printer) line(text string) {
+	pr.out.WriteString(text)
+	pr.out.WriteByte('\n')
+	pr.lastLine = text != ""
+}
+
+func (pr *printer) blank() {
+	if pr.lastLine {
+		pr.line("")
+	}
+}
+
+func (pr *printer) comment(c *Comment, blankBefore bool) {
+	if blankBefore {
+		pr.blank()
+	}
+	pr.line(strings.TrimRight("#"+c.Text, " \t"))
+}
+
+func (pr *printer) messages(block []*Message) {
+	longestKey := 0
+	for _, msg := range block {
+		if !msg.IsEmpty() {
+			longestKey = max(longestKey, utf8.RuneCountInString(msg.Key))
+		}
+	}
+	column := longestKey + pr.opts.MinimumSpacing
+
+	for _, msg := range block {
+		for i, c := range msg.Comments {
+			// the blank line before the first comment is handled by the alignment blocks
+			pr.comment(c, c.BlankBefore && i > 0)
+		}
+		if msg.BlankBefore && len(msg.Comments) > 0 {
+			pr.blank()
+		}
+
+		if msg.IsEmpty() {
+			pr.line(msg.Key)
+			continue
+		}
+
+		tokens := layoutText(msg.Text, pr.opts.CollapseConditionals)
+		if pr.opts.Inline {
+			keyWidth := utf8.RuneCountInString(msg.Key)
+			spacing := min(max(column-keyWidth, pr.opts.MinimumSpacing), pr.opts.MaximumSpacing)
+			pr.fill(tokens, msg.Key+strings.Repeat(" ", spacing), keyWidth+spacing)
+		} else {
+			pr.line(msg.Key)
+			indent, width := pr.indentation(0)
+			pr.fill(tokens, indent, width)
+		}
+	}
+}
+
+// Return the indentation of a continuation line at [depth], and its width.
+func (pr *printer) indentation(depth int) (string, int) {
+	units := pr.opts.Indent * (depth + 1)
+	if pr.opts.UseTabs {
+		return strings.Repeat("\t", units), units * tabWidth
+	} else {
+		return strings.Repeat(" ", units), units
+	}
+}
+
+// Print tokens on as many lines as needed, starting on a line that already contains [start].
+func (pr *printer) fill(tokens []token, start string, startWidth int) {
+	var (
+		line    strings.Builder
+		width   = startWidth
+		empty   = true
+		spacing = false
+		depth   = 0
+	)
+	line.WriteString(start)
+	breakLine := func() {
+		pr.line(line.String())
+		line.Reset()
+		indent, indentWidth := pr.indentation(depth)
+		line.WriteString(indent)
+		width = indentWidth
+		empty = true
+		spacing = false
+	}
+
+	for _, tok := range tokens {
+		switch tok.kind {
+		case wordToken:
+			wordWidth := utf8.RuneCountInString(tok.text)
+			if !empty && spacing {
+				if width+1+wordWidth > pr.opts.PrintWidth {
+					breakLine()
+				} else {
+					line.WriteByte(' ')
+					width++
+				}
+			}
+			line.WriteString(tok.text)
+			width += wordWidth
+			empty = false
+			spacing = false
+		case softBreak:
+			spacing = true
+		case hardBreak:
+			depth = tok.depth
+			breakLine()
+		}
+	}
+	pr.line(line.String())
+}
+
+type tokenKind uint8
+
+const (
+	wordToken tokenKind = iota
+	// a single space that may be replaced by a line break
+	softBreak
+	// a line break followed by an indentation of the given depth
+	hardBreak
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	depth int
+}
+
+// Turns a message text into words separated by breakable spaces.
+type layout struct {
+	tokens   []token
+	word     strings.Builder
+	depth    int
+	collapse bool
+}
+
+func layoutText(text Text, collapseConditionals bool) []token {
+	l := &layout{collapse: collapseConditionals}
+	l.text(text, false)
+	l.flush()
+	return l.tokens
+}
+
+func (l *layout) flush() {
+	if l.word.Len() > 0 {
+		l.tokens = append(l.tokens, token{kind: wordToken, text: l.word.String()})
+		l.word.Reset()
+	}
+}
+
+func (l *layout) soft() {
+	l.flush()
+	if n := len(l.tokens); n > 0 && l.tokens[n-1].kind == wordToken {
+		l.tokens = append(l.tokens, token{kind: softBreak})
+	}
+}
+
+func (l *layout) hard() {
+	l.flush()
+	if n := len(l.tokens); n > 0 && l.tokens[n-1].kind == softBreak {
+		l.tokens = l.tokens[:n-1]
+	}
+	l.tokens = append(l.tokens, token{kind: hardBreak, depth: l.depth})
+}
+
+func (l *layout) text(text Text, inCase bool) {
+	for i, seg := range text {
+		switch seg := seg.(type) {
+		case *Literal:
+			l.literal(seg.Value, inCase, i == 0, i == len(text)-1)
+		case *Placeholder:
+			l.word.WriteString("{" + seg.Name)
+			if seg.Type != "" {
+				l.word.WriteString(":" + string(seg.Type))
+			}
+			l.word.WriteString("}")
+		case *Conditional:
+			l.conditional(seg)
+		}
+	}
+}
+
+func (l *layout) conditional(cond *Conditional) {
+	l.word.WriteString("{" + cond.Name)
+	if cond.Type != "" {
+		l.word.WriteString(":" + string(cond.Type))
+	}
+	if l.collapse {
+		l.soft()
+		l.word.WriteString("?")
+	} else {
+		l.word.WriteString(" ?")
+		l.depth++
+	}
+
+	for i, c := range cond.Cases {
+		if l.collapse {
+			l.soft()
+		} else {
+			l.hard()
+		}
+		l.word.WriteString(formatSelector(c.Selector))
+		l.soft()
+		l.word.WriteString("=>")
+		l.soft()
+		l.text(c.Text, true)
+		if i < len(cond.Cases)-1 {
+			l.soft()
+			l.word.WriteString("|")
+		}
+	}
+
+	if !l.collapse {
+		l.depth--
+		l.hard()
+	}
+	l.word.WriteString("}")
+}
+
+func formatSelector(sel Selector) string {
+	if sel.Kind != WordSelector {
+		return sel.Value
+	}
+	isIdent := sel.Value != "" && sel.Value != "true" && sel.Value != "false"
+	for i, r := range sel.Value {
+		isIdent = isIdent && isIdentRune(r, i == 0)
+	}
+	if isIdent {
+		return sel.Value
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(sel.Value)
+	return `"` + escaped + `"`
+}
+
+// Write a literal, escaping the characters that have a special meaning and the spaces that would be trimmed.
+func (l *layout) literal(value string, inCase bool, first bool, last bool) {
+	for i := 0; i < len(value); {
+		if value[i] == ' ' {
+			n := len(value[i:]) - len(strings.TrimLeft(value[i:], " "))
+			atStart := first && i == 0
+			atEnd := last && i+n == len(value)
+			if atStart && atEnd && n == 1 {
+				l.word.WriteString(`\ `)
+			} else {
+				raw := n
+				if atStart {
+					l.word.WriteString(`\ `)
+					raw--
+				}
+				if atEnd {
+					raw--
+				}
+				if raw == 1 {
+					l.soft()
+				} else {
+					l.word.WriteString(strings.Repeat(" ", raw))
+				}
+				if atEnd {
+					l.word.WriteString(`\ `)
+				}
+			}
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(value[i:])
+		switch r {
+		case '\\', '{', '}':
+			l.word.WriteString(`\` + string(r))
+		case '|':
+			if inCase {
+				l.word.WriteString(`\|`)
+			} else {
+				l.word.WriteRune(r)
+			}
+		case '\n':
+			l.word.WriteString(`\n`)
+		case '\t':
+			l.word.WriteString(`\t`)
+		default:
+			l.word.WriteRune(r)
+		}
+		i += size
+	}
+}
+
+// Return the text as it would be written in a translation file, on a single line.
+func (text Text) String() string {
+	var line strings.Builder
+	for _, tok := range layoutText(text, true) {
+		if tok.kind == softBreak {
+			line.WriteByte(' ')
+		} else {
+			line.WriteString(tok.text)
+		}
+	}
+	return line.String()
+}
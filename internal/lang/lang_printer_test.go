@@ -0,0 +1,121 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+const unformatted = `# Header
+
+# Shown on the home page.
+greeting Hello,   {name}!
+inbox.count
+	You have {count?0=>no messages|1=>one message|*=>{count} messages} in your inbox.
+
+farewell    See you\ 
+empty
+`
+
+func assertFormatted(t *testing.T, opts lang.FormatOptions, expected string) {
+	file, err := lang.Parse("test.elz", []byte(unformatted))
+	if err != nil {
+		t.Fatalf("error parsing test file: %s", err)
+	}
+	formatted := string(lang.Format(file, opts))
+	if formatted != expected {
+		t.Logf("Expected formatted file to be:\n%s", expected)
+		t.Logf("but got:\n%s", formatted)
+		t.FailNow()
+	}
+
+	// formatting must not change the meaning of the file, and formatting twice must give the same result
+	reparsed, err := lang.Parse("test.elz", []byte(formatted))
+	if err != nil {
+		t.Fatalf("error parsing formatted file: %s", err)
+	}
+	if twice := string(lang.Format(reparsed, opts)); twice != formatted {
+		t.Fatalf("expected formatting to be idempotent but got:\n%s", twice)
+	}
+}
+
+func TestFormatInline(t *testing.T) {
+	assertFormatted(t, lang.FormatOptions{
+		PrintWidth:           40,
+		Inline:               true,
+		Indent:               1,
+		UseTabs:              true,
+		MinimumSpacing:       2,
+		MaximumSpacing:       16,
+		CollapseConditionals: true,
+		SortMessages:         project.Append,
+	}, `# Header
+
+# Shown on the home page.
+greeting     Hello,   {name}!
+inbox.count  You have {count ? 0 => no
+	messages | 1 => one message | * =>
+	{count} messages} in your inbox.
+
+farewell  See you\ 
+empty
+`)
+}
+
+func TestFormatExpanded(t *testing.T) {
+	assertFormatted(t, lang.FormatOptions{
+		PrintWidth:           80,
+		Inline:               false,
+		Indent:               2,
+		UseTabs:              false,
+		MinimumSpacing:       1,
+		MaximumSpacing:       1,
+		CollapseConditionals: false,
+		SortMessages:         project.Alphabetical,
+	}, `# Header
+
+empty
+farewell
+  See you\ 
+# Shown on the home page.
+greeting
+  Hello,   {name}!
+inbox.count
+  You have {count ?
+    0 => no messages |
+    1 => one message |
+    * => {count} messages
+  } in your inbox.
+`)
+}
+
+func TestFormatSpacing(t *testing.T) {
+	file, err := lang.Parse("test.elz", []byte("a x\nabcdefghijkl y\nabc z\n"))
+	if err != nil {
+		t.Fatalf("error parsing test file: %s", err)
+	}
+	opts := lang.FormatOptions{PrintWidth: 80, Inline: true, Indent: 1, MinimumSpacing: 1, MaximumSpacing: 4}
+	expected := "a    x\nabcdefghijkl y\nabc    z\n"
+	if formatted := string(lang.Format(file, opts)); formatted != expected {
+		t.Fatalf("expected spacing to be clamped between 1 and 4 but got:\n%s", formatted)
+	}
+}
+
+func TestFormatSourceOrder(t *testing.T) {
+	file, err := lang.Parse("test.elz", []byte("b x\nc y\na z\n"))
+	if err != nil {
+		t.Fatalf("error parsing test file: %s", err)
+	}
+	file.Add("d")
+	file.Remove("c")
+
+	opts := lang.FormatOptions{
+		PrintWidth: 80, Inline: true, Indent: 1, MinimumSpacing: 1, MaximumSpacing: 1,
+		SortMessages: project.Source, SourceOrder: []string{"d", "a"},
+	}
+	expected := "d\na z\nb x\n"
+	if formatted := string(lang.Format(file, opts)); formatted != expected {
+		t.Fatalf("expected messages to follow the source order but got:\n%s", formatted)
+	}
+}
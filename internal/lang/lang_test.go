@@ -0,0 +1,187 @@
+package lang_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+func TestParseFileName(t *testing.T) {
+	prefix, locale, err := lang.ParseFileName("src/lang/fr-CA.elz")
+	if err != nil || prefix != project.NoPrefix || locale != "fr-CA" {
+		t.Fatalf("expected fr-CA.elz to have no prefix and locale fr-CA but got %q, %q (%v)", prefix, locale, err)
+	}
+
+	prefix, locale, err = lang.ParseFileName("src/lang/ui.en.elz")
+	if err != nil || prefix != "ui" || locale != "en" {
+		t.Fatalf("expected ui.en.elz to have prefix ui and locale en but got %q, %q (%v)", prefix, locale, err)
+	}
+
+	for _, name := range []string{"a.b.c.elz", ".en.elz", "en.yml"} {
+		if _, _, err := lang.ParseFileName(name); err == nil {
+			t.Fatalf("expected %s not to be a valid file name", name)
+		}
+	}
+}
+
+func TestIsValidLocale(t *testing.T) {
+	for _, locale := range []string{"en", "fr-CA", "zh_Hant_TW", "es-419"} {
+		if !lang.IsValidLocale(locale) {
+			t.Fatalf("expected %s to be a valid locale", locale)
+		}
+	}
+	for _, locale := range []string{"", "1en", "-fr", "ui.en", "en/US"} {
+		if lang.IsValidLocale(locale) {
+			t.Fatalf("expected %q not to be a valid locale", locale)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	file, err := lang.ParseFile("testdata/en.elz")
+	if err != nil {
+		t.Fatalf("error parsing en.elz: %s", err)
+	}
+
+	if file.Locale != "en" || file.Prefix != project.NoPrefix {
+		t.Fatalf("expected locale en and no prefix but got %q, %q", file.Locale, file.Prefix)
+	}
+	if len(file.Header) != 1 || file.Header[0].Text != " Messages shared by the whole application." {
+		t.Fatalf("expected one header comment but got %v", file.Header)
+	}
+	if len(file.Messages) != 3 {
+		t.Fatalf("expected 3 messages but got %d", len(file.Messages))
+	}
+	if len(file.Trailer) != 1 {
+		t.Fatalf("expected 1 trailing comment but got %d", len(file.Trailer))
+	}
+
+	greeting := file.Lookup("greeting")
+	if greeting == nil || greeting.Pos != (lang.Pos{Line: 4, Column: 1}) {
+		t.Fatalf("expected greeting to be at 4:1 but got %v", greeting)
+	}
+	if len(greeting.Comments) != 1 || !greeting.Comments[0].BlankBefore {
+		t.Fatalf("expected greeting to have 1 comment after a blank line but got %v", greeting.Comments)
+	}
+	if len(greeting.Text) != 3 {
+		t.Fatalf("expected greeting to have 3 segments but got %d", len(greeting.Text))
+	}
+	if lit, ok := greeting.Text[0].(*lang.Literal); !ok || lit.Value != "Hello, " || lit.Pos.Column != 11 {
+		t.Fatalf("expected greeting to start with \"Hello, \" at column 11 but got %#v", greeting.Text[0])
+	}
+	if ph, ok := greeting.Text[1].(*lang.Placeholder); !ok || ph.Name != "name" || ph.Pos.Column != 18 {
+		t.Fatalf("expected {name} at column 18 but got %#v", greeting.Text[1])
+	}
+
+	count := file.Lookup("inbox.count")
+	cond, ok := count.Text[1].(*lang.Conditional)
+	if !ok || cond.Name != "count" || cond.ResolvedType() != lang.Int || len(cond.Cases) != 3 {
+		t.Fatalf("expected a conditional on {count} with 3 cases but got %#v", count.Text[1])
+	}
+	if lit, ok := cond.Cases[1].Text[0].(*lang.Literal); !ok || lit.Value != "one message" {
+		t.Fatalf("expected the second case to be \"one message\" but got %#v", cond.Cases[1].Text[0])
+	}
+	if lit, ok := count.Text[2].(*lang.Literal); !ok || lit.Value != " in your inbox." {
+		t.Fatalf("expected line break to be read as a space but got %#v", count.Text[2])
+	}
+	if cond.Pos != (lang.Pos{Line: 6, Column: 11}) {
+		t.Fatalf("expected conditional to be at 6:11 but got %v", cond.Pos)
+	}
+
+	farewell := file.Lookup("farewell")
+	if lit, ok := farewell.Text[0].(*lang.Literal); !ok || lit.Value != "See you " {
+		t.Fatalf("expected escaped trailing space to be kept but got %#v", farewell.Text[0])
+	}
+	if !farewell.BlankBefore {
+		t.Fatal("expected farewell to be preceded by a blank line")
+	}
+}
+
+func TestParams(t *testing.T) {
+	file, err := lang.ParseFile("testdata/ui.fr.elz")
+	if err != nil {
+		t.Fatalf("error parsing ui.fr.elz: %s", err)
+	}
+
+	params := file.Lookup("price").Params()
+	if len(params) != 1 || params[0] != (lang.Param{Name: "amount", Type: lang.Float}) {
+		t.Fatalf("expected price to take [amount float] but got %v", params)
+	}
+
+	params = file.Lookup("admin").Params()
+	if len(params) != 1 || params[0] != (lang.Param{Name: "isAdmin", Type: lang.Bool}) {
+		t.Fatalf("expected admin to take [isAdmin bool] but got %v", params)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	text, err := lang.ParseText("<text>", "Hello {name},\nyou have {n:int} messages")
+	if err != nil {
+		t.Fatalf("error parsing text: %s", err)
+	}
+	if text.String() != "Hello {name}, you have {n:int} messages" {
+		t.Fatalf("expected line breaks to be folded but got %q", text.String())
+	}
+
+	_, err = lang.ParseText("<text>", "Hello {name")
+	if err == nil || !strings.HasPrefix(err.Error(), "<text>:1:") {
+		t.Fatalf("expected a syntax error in <text> but got %v", err)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	src, err := os.ReadFile("testdata/invalid.txt")
+	if err != nil {
+		panic(err)
+	}
+
+	file, err := lang.Parse("invalid.txt", src)
+	var list lang.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("expected an error list but got %v", err)
+	}
+
+	expected := []string{
+		"invalid.txt:1:3: unexpected indentation (no message to continue)",
+		"invalid.txt:2:4: unexpected character '}' in message key",
+		"invalid.txt:3:5: conditional on {count} has no default case \"*\"",
+		"invalid.txt:5:1: duplicate key \"dup\" (first defined at 4:1)",
+		"invalid.txt:6:9: unknown type \"integer\" (expected string, int, float or bool)",
+		"invalid.txt:7:6: unknown escape sequence \"\\q\"",
+		"invalid.txt:8:19: {n} is used both as int and string",
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d errors but got %d: %v", len(expected), len(list), list.Unwrap())
+	}
+	for i, e := range expected {
+		if list[i].Error() != e {
+			t.Logf("Expected error %d to be \"%s\"", i+1, e)
+			t.Logf("               but got \"%s\"", list[i])
+			t.FailNow()
+		}
+	}
+
+	if file.Lookup("dup") == nil {
+		t.Fatal("expected messages to be returned despite the errors")
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	catalog, err := lang.LoadDir("testdata")
+	if err != nil {
+		t.Fatalf("error loading testdata: %s", err)
+	}
+	if len(catalog.Files) != 2 {
+		t.Fatalf("expected 2 files but got %d", len(catalog.Files))
+	}
+	if locales := catalog.Locales(); len(locales) != 2 || locales[0] != "en" || locales[1] != "fr" {
+		t.Fatalf("expected locales [en fr] but got %v", locales)
+	}
+	if catalog.Get("ui", "fr") == nil {
+		t.Fatal("expected to find ui.fr.elz")
+	}
+}
@@ -1,10 +1,15 @@
 package project
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"go/token"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/louisdevie/elizalina2/internal/ymlcfg"
 )
@@ -12,77 +17,410 @@ import (
 const NoPrefix = "$"
 
 type Config interface {
+	// The directory containing the configuration file, which relative paths start from.
+	Dir() string
 	Sources() (map[string][]string, error)
 	Ignore() ([]string, error)
 	Translations() (string, error)
-	// Format() FormatConfig
+	// The locale messages are written in first, used when a message is not translated.
+	SourceLocale() (string, error)
+	Format() FormatConfig
+	Go() GoConfig
+	JS() JSConfig
+	// The keys of the configuration that are not read by any of the other methods, which are probably typos.
+	Unused() []ymlcfg.UnusedKey
 }
 
-// type FormatConfig interface {
-// 	PrintWidth() (int, error)
-// 	Inline() (bool, error)
-// 	Indent() (int, error)
-// 	UseTabs() (bool, error)
-// 	MinimumSpacing() (int, error)
-// 	MaximumSpacing() (int, error)
-// 	CollapseConditionals() (bool, error)
-// 	SortMessages() (MessageSort, error)
-// }
+type FormatConfig interface {
+	PrintWidth() (int, error)
+	Inline() (bool, error)
+	Indent() (int, error)
+	UseTabs() (bool, error)
+	MinimumSpacing() (int, error)
+	MaximumSpacing() (int, error)
+	CollapseConditionals() (bool, error)
+	SortMessages() (MessageSort, error)
+}
+
+type GoConfig interface {
+	// The directory of the generated package. Go code is only generated if this is set.
+	Output() (string, error)
+	Package() (string, error)
+	// The name of the function used to translate messages in the sources.
+	TranslateFn() (string, error)
+}
+
+type JSConfig interface {
+	// The directory of the generated modules. JavaScript code is only generated if this is set.
+	Output() (string, error)
+	Module() (JSModule, error)
+	Minify() (bool, error)
+	// A file re-exporting the generated modules, so that they can be imported from the sources.
+	EntryPoint() (string, error)
+	// The name of the function used to translate messages in the sources.
+	TranslateFn() (string, error)
+}
+
+type JSModule uint8
+
+const (
+	ESModule JSModule = iota
+	CommonJS
+)
 
-// type MessageSort uint8
+type MessageSort uint8
 
-// const (
-// 	Append MessageSort = iota
-// 	Alphabetical
-// 	Source
-// )
+const (
+	Append MessageSort = iota
+	Alphabetical
+	Source
+)
+
+// The keys of the configuration file. Sections are read when their accessor is called.
+type rootSection struct {
+	Extends      string        `ymlcfg:"extends" doc:"Another configuration file whose keys are used when missing from this one."`
+	Sources      sourcesValue  `ymlcfg:"sources" doc:"Patterns of the files searched for messages, or a mapping from prefixes to patterns."`
+	Ignore       []string      `ymlcfg:"ignore" doc:"Patterns of files left out of every prefix."`
+	Translations string        `ymlcfg:"translations" doc:"The directory containing the translation files."`
+	SourceLocale string        `ymlcfg:"sourceLocale" doc:"The locale used when a message is not translated."`
+	Format       formatSection `ymlcfg:"format" doc:"How translation files are formatted."`
+	Go           goSection     `ymlcfg:"go" doc:"The Go package generated by elz release."`
+	JS           jsSection     `ymlcfg:"js" doc:"The JavaScript modules generated by elz release."`
+}
+
+// The sources of each prefix, written as a single pattern, a list of patterns or a mapping from prefixes to patterns.
+type sourcesValue map[string][]string
+
+func (sv *sourcesValue) BindConfig(cfg ymlcfg.ConfigValue) (err error) {
+	if cfg.Kind() == ymlcfg.Mapping {
+		*sv, err = ymlcfg.BindMap(cfg, ymlcfg.ConfigValue.BindStrSeq)
+		return err
+	}
+	value, err := cfg.BindStrSeq()
+	*sv = sourcesValue{NoPrefix: value}
+	return err
+}
+
+func (sv *sourcesValue) ConfigSchema() map[string]any {
+	patterns := map[string]any{
+		"anyOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	return map[string]any{
+		"anyOf": []any{
+			patterns,
+			map[string]any{"type": "object", "additionalProperties": patterns},
+		},
+	}
+}
+
+// Return the JSON schema of the configuration file.
+func JSONSchema() ([]byte, error) {
+	schema := ymlcfg.Schema(rootSection{})
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = StdConfigFileName
+	data, err := json.MarshalIndent(schema, "", "  ")
+	return append(data, '\n'), err
+}
 
 type ConfigFile struct {
-	root ymlcfg.ConfigValue
+	dir     string
+	root    ymlcfg.ConfigValue
+	section rootSection
 }
 
-func (cf *ConfigFile) Sources() (value map[string][]string, err error) {
-	value, ok := ymlcfg.BindMap(cf.root.Get("sources"), ymlcfg.ConfigValue.BindStrSeq)
-	if !ok {
-		value[NoPrefix], ok = cf.root.Get("sources").BindStrSeq()
+func (cf *ConfigFile) Dir() string {
+	return cf.dir
+}
+
+func (cf *ConfigFile) Sources() (map[string][]string, error) {
+	err := ymlcfg.BindField(cf.root, &cf.section, "Sources")
+	return cf.section.Sources, err
+}
+
+func (cf *ConfigFile) Ignore() ([]string, error) {
+	err := ymlcfg.BindField(cf.root, &cf.section, "Ignore")
+	return cf.section.Ignore, err
+}
+
+func (cf *ConfigFile) Translations() (string, error) {
+	err := ymlcfg.BindField(cf.root, &cf.section, "Translations")
+	return cf.section.Translations, err
+}
+
+func (cf *ConfigFile) SourceLocale() (string, error) {
+	err := ymlcfg.BindField(cf.root, &cf.section, "SourceLocale")
+	return cf.section.SourceLocale, err
+}
+
+// The keys of the format section and their defaults.
+type formatSection struct {
+	PrintWidth           int         `ymlcfg:"printWidth" default:"80" min:"1" doc:"The maximum length of lines."`
+	Inline               bool        `ymlcfg:"inline" default:"true" doc:"Write the text of messages on the same line as their key."`
+	Indent               int         `ymlcfg:"indent" default:"1" min:"1" doc:"The indentation of continuation lines."`
+	UseTabs              bool        `ymlcfg:"useTabs" default:"true" doc:"Indent with tabs instead of spaces."`
+	MinimumSpacing       int         `ymlcfg:"minimumSpacing" default:"2" min:"1" doc:"The minimum space between keys and text."`
+	MaximumSpacing       int         `ymlcfg:"maximumSpacing" min:"1" doc:"The maximum space between keys and text (16 by default)."`
+	CollapseConditionals bool        `ymlcfg:"collapseConditionals" default:"true" doc:"Write conditionals on a single line when they fit."`
+	SortMessages         MessageSort `ymlcfg:"sortMessages" enum:"append,alphabetical,source" doc:"The order of messages in translation files."`
+}
+
+func (cf *ConfigFile) Format() FormatConfig {
+	return &formatConfig{root: cf.root.Get("format")}
+}
+
+type formatConfig struct {
+	root    ymlcfg.ConfigValue
+	section formatSection
+}
+
+func (fc *formatConfig) PrintWidth() (int, error) {
+	err := ymlcfg.BindField(fc.root, &fc.section, "PrintWidth")
+	return fc.section.PrintWidth, err
+}
+
+func (fc *formatConfig) Inline() (bool, error) {
+	err := ymlcfg.BindField(fc.root, &fc.section, "Inline")
+	return fc.section.Inline, err
+}
+
+func (fc *formatConfig) Indent() (int, error) {
+	err := ymlcfg.BindField(fc.root, &fc.section, "Indent")
+	return fc.section.Indent, err
+}
+
+func (fc *formatConfig) UseTabs() (bool, error) {
+	err := ymlcfg.BindField(fc.root, &fc.section, "UseTabs")
+	return fc.section.UseTabs, err
+}
+
+func (fc *formatConfig) MinimumSpacing() (int, error) {
+	err := ymlcfg.BindField(fc.root, &fc.section, "MinimumSpacing")
+	return fc.section.MinimumSpacing, err
+}
+
+// The maximum spacing defaults to 16 and cannot be less than the minimum spacing.
+func (fc *formatConfig) MaximumSpacing() (int, error) {
+	minSpacing, err := fc.MinimumSpacing()
+	if err != nil {
+		return minSpacing, err
 	}
-	if !ok {
-		err = fmt.Errorf("sources should be a string, a list of strings or a mapping")
+	return ymlcfg.BindInt(fc.root.Get("maximumSpacing"), max(minSpacing, 16), minSpacing, math.MaxInt)
+}
+
+func (fc *formatConfig) CollapseConditionals() (bool, error) {
+	err := ymlcfg.BindField(fc.root, &fc.section, "CollapseConditionals")
+	return fc.section.CollapseConditionals, err
+}
+
+func (fc *formatConfig) SortMessages() (MessageSort, error) {
+	err := ymlcfg.BindField(fc.root, &fc.section, "SortMessages")
+	return fc.section.SortMessages, err
+}
+
+// The keys of the go section and their defaults.
+type goSection struct {
+	Output      string `ymlcfg:"output" doc:"The directory of the generated package."`
+	Package     string `ymlcfg:"package" doc:"The name of the package (the name of the output directory by default)."`
+	TranslateFn string `ymlcfg:"translateFn" default:"T" doc:"The name of the translate function in the sources."`
+}
+
+func (cf *ConfigFile) Go() GoConfig {
+	return &goConfig{root: cf.root.Get("go")}
+}
+
+type goConfig struct {
+	root    ymlcfg.ConfigValue
+	section goSection
+}
+
+func (gc *goConfig) Output() (string, error) {
+	err := ymlcfg.BindField(gc.root, &gc.section, "Output")
+	return gc.section.Output, err
+}
+
+// The name of the generated package, which defaults to the name of the output directory.
+func (gc *goConfig) Package() (string, error) {
+	if err := ymlcfg.BindField(gc.root, &gc.section, "Package"); err != nil {
+		return "", err
+	}
+	value := gc.section.Package
+	if value == "" {
+		output, err := gc.Output()
+		if err != nil || output == "" {
+			return "", err
+		}
+		value = filepath.Base(output)
 	}
-	return value, err
+	if !token.IsIdentifier(value) {
+		return "", gc.root.Get("package").Errorf("expected a valid package name, but got %q", value)
+	}
+	return value, nil
 }
 
-func (cf *ConfigFile) Ignore() (value []string, err error) {
-	value, ok := cf.root.Get("ignore").BindStrSeq()
-	if !ok {
-		err = fmt.Errorf("ignore should be a string or a list of strings")
+func (gc *goConfig) TranslateFn() (string, error) {
+	err := ymlcfg.BindField(gc.root, &gc.section, "TranslateFn")
+	if err == nil && !token.IsIdentifier(gc.section.TranslateFn) {
+		err = gc.root.Get("translateFn").Errorf("expected a valid function name, but got %q", gc.section.TranslateFn)
 	}
-	return value, err
+	return gc.section.TranslateFn, err
 }
 
-func (cf *ConfigFile) Translations() (value string, err error) {
-	value, ok := cf.root.Get("translations").BindStr()
-	if !ok {
-		err = fmt.Errorf("translations should be a string")
+// The keys of the js section and their defaults.
+type jsSection struct {
+	Output      string   `ymlcfg:"output" doc:"The directory of the generated modules."`
+	Module      JSModule `ymlcfg:"module" enum:"esm,cjs|commonjs" doc:"The kind of modules to generate."`
+	Minify      bool     `ymlcfg:"minify" default:"false" doc:"Minify the generated code."`
+	EntryPoint  string   `ymlcfg:"entryPoint" doc:"A module re-exporting the generated modules."`
+	TranslateFn string   `ymlcfg:"translateFn" default:"__" doc:"The name of the translate function in the sources."`
+}
+
+func (cf *ConfigFile) JS() JSConfig {
+	return &jsConfig{root: cf.root.Get("js")}
+}
+
+type jsConfig struct {
+	root    ymlcfg.ConfigValue
+	section jsSection
+}
+
+func (jc *jsConfig) Output() (string, error) {
+	err := ymlcfg.BindField(jc.root, &jc.section, "Output")
+	return jc.section.Output, err
+}
+
+func (jc *jsConfig) Module() (JSModule, error) {
+	err := ymlcfg.BindField(jc.root, &jc.section, "Module")
+	return jc.section.Module, err
+}
+
+func (jc *jsConfig) Minify() (bool, error) {
+	err := ymlcfg.BindField(jc.root, &jc.section, "Minify")
+	return jc.section.Minify, err
+}
+
+func (jc *jsConfig) EntryPoint() (string, error) {
+	err := ymlcfg.BindField(jc.root, &jc.section, "EntryPoint")
+	return jc.section.EntryPoint, err
+}
+
+func (jc *jsConfig) TranslateFn() (string, error) {
+	err := ymlcfg.BindField(jc.root, &jc.section, "TranslateFn")
+	return jc.section.TranslateFn, err
+}
+
+func (cf *ConfigFile) Unused() []ymlcfg.UnusedKey {
+	// every value is read once so that the keys they come from are marked as used, errors are reported elsewhere
+	ymlcfg.Bind(cf.root, &rootSection{})
+	// the maximum spacing is read by its accessor, with a range depending on the minimum spacing
+	cf.Format().MaximumSpacing()
+
+	return ymlcfg.Unused(cf.root)
+}
+
+// A value of the configuration set outside of the configuration file.
+type Override struct {
+	// The full key of the value, like "format.printWidth".
+	Key string
+	// The value, read as YAML.
+	Value string
+	// Where the value comes from, used in errors instead of a file name.
+	Origin string
+}
+
+// Parse an override written key=value, like the value of the --config flag.
+func ParseOverride(arg string) (Override, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	if !ok || key == "" {
+		return Override{}, fmt.Errorf("expected key=value but got %q", arg)
 	}
-	return value, err
+	return Override{Key: key, Value: value, Origin: "--config " + arg}, nil
 }
 
-func LoadConfigFile(path string) (Config, error) {
-	data, err := os.ReadFile(path)
+// The environment variable overriding a key, like ELZ_FORMAT_PRINTWIDTH for format.printWidth.
+func EnvVariable(key string) string {
+	return "ELZ_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Read a configuration file, merged with the files it extends. Paths in the merged configuration are relative to the
+// directory of [path], wherever they were defined.
+//
+// Variables like ${NAME} or ${NAME:-default} in the values are replaced with environment variables. Then every key can
+// be overridden by its environment variable (see EnvVariable), and finally by [overrides].
+func LoadConfigFile(path string, overrides ...Override) (Config, error) {
+	cv, err := loadConfigValue(path, nil)
 	if err != nil {
 		return &ConfigFile{}, err
 	}
+	if cv, err = ymlcfg.Interpolate(cv, os.LookupEnv); err != nil {
+		return &ConfigFile{}, err
+	}
+
+	var env []Override
+	for _, key := range ConfigKeys() {
+		if value, ok := os.LookupEnv(EnvVariable(key)); ok && key != "extends" {
+			env = append(env, Override{Key: key, Value: value, Origin: "$" + EnvVariable(key)})
+		}
+	}
+	return WithOverrides(&ConfigFile{dir: filepath.Dir(path), root: cv}, slices.Concat(env, overrides)...)
+}
+
+// Return a copy of [cfg] with some of its values replaced. The copy is read independently of [cfg].
+func WithOverrides(cfg Config, overrides ...Override) (Config, error) {
+	file, ok := cfg.(*ConfigFile)
+	if !ok {
+		return cfg, errors.New("this configuration cannot be overridden")
+	}
+	root := file.root
+	for _, override := range overrides {
+		var err error
+		if root, err = ymlcfg.Override(root, override.Key, override.Value, override.Origin); err != nil {
+			return &ConfigFile{}, err
+		}
+	}
+	return &ConfigFile{dir: file.dir, root: root}, nil
+}
 
-	cv, err := ymlcfg.FromText(data)
+// The full keys of the configuration file, like "format.printWidth".
+func ConfigKeys() []string {
+	return ymlcfg.Keys(rootSection{})
+}
+
+// Read a configuration file and the files it extends, [chain] being the absolute paths of the files extending it.
+func loadConfigValue(path string, chain []string) (ymlcfg.ConfigValue, error) {
+	cv, err := ymlcfg.FromFile(path)
 	if err != nil {
-		return &ConfigFile{}, err
+		return cv, err
+	}
+
+	var section rootSection
+	if err := ymlcfg.BindField(cv, &section, "Extends"); err != nil || section.Extends == "" {
+		return cv, err
+	}
+	extends := cv.Get("extends")
+	basePath := filepath.Join(filepath.Dir(path), filepath.FromSlash(section.Extends))
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return cv, err
+	}
+	chain = append(chain, abs)
+	if baseAbs, err := filepath.Abs(basePath); err == nil && slices.Contains(chain, baseAbs) {
+		return cv, extends.Errorf("%s is already extended by this file (extends cannot be circular)", section.Extends)
+	}
+	if _, err := os.Stat(basePath); err != nil {
+		return cv, extends.Errorf("could not read %s", basePath)
 	}
 
-	return &ConfigFile{root: cv}, err
+	base, err := loadConfigValue(basePath, chain)
+	if err != nil {
+		return cv, err
+	}
+	return ymlcfg.Merge(base, cv), nil
 }
 
-const stdConfigFileName = "elz.config.yml"
+const StdConfigFileName = "elz.config.yml"
 
 func FindConfigFile(path string) (file string, found bool) {
 	path, err := filepath.Abs(path)
@@ -90,8 +428,8 @@ func FindConfigFile(path string) (file string, found bool) {
 		panic(err)
 	}
 	for !found {
-		expectedConfig := filepath.Join(path, stdConfigFileName)
-		_, err := os.Stat(path)
+		expectedConfig := filepath.Join(path, StdConfigFileName)
+		_, err := os.Stat(expectedConfig)
 		if errors.Is(err, os.ErrNotExist) {
 			parent := filepath.Dir(path)
 			if parent == path {
@@ -0,0 +1,182 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Replace the sources section of the configuration file at [path]. The comments and the other sections of the file
+// are kept, and prefixes that were already present keep their place.
+func WriteSources(path string, sources map[string][]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: the configuration should be a mapping", path)
+	}
+
+	value := sourcesNode(findValue(root, "sources"), sources)
+	if i := findKey(root, "sources"); i >= 0 {
+		// a comment on the same line as a scalar value stays on the line of the key
+		key, existing := root.Content[i], root.Content[i+1]
+		if existing.Kind == yaml.ScalarNode && key.LineComment == "" {
+			key.LineComment, value.LineComment = existing.LineComment, ""
+		}
+		root.Content[i+1] = value
+	} else {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "sources"}, value)
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0666)
+}
+
+// Return the index of [key] in a mapping node, or -1 if it is not present.
+func findKey(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Return the value of [key] in a mapping node, or <nil> if it is not present.
+func findValue(mapping *yaml.Node, key string) *yaml.Node {
+	if i := findKey(mapping, key); i >= 0 {
+		return mapping.Content[i+1]
+	}
+	return nil
+}
+
+// Build the node of the sources section, reusing the keys of the previous one.
+func sourcesNode(previous *yaml.Node, sources map[string][]string) *yaml.Node {
+	var prefixes []string
+	if previous != nil && previous.Kind == yaml.MappingNode {
+		for i := 0; i < len(previous.Content); i += 2 {
+			if _, ok := sources[previous.Content[i].Value]; ok {
+				prefixes = append(prefixes, previous.Content[i].Value)
+			}
+		}
+	}
+	var added []string
+	for prefix := range sources {
+		if !slices.Contains(prefixes, prefix) {
+			added = append(added, prefix)
+		}
+	}
+	slices.Sort(added)
+	prefixes = append(prefixes, added...)
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	if previous != nil {
+		node.HeadComment, node.LineComment, node.FootComment = previous.HeadComment, previous.LineComment,
+			previous.FootComment
+	}
+	for _, prefix := range prefixes {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: prefix}
+		if previous != nil && previous.Kind == yaml.MappingNode {
+			for i := 0; i < len(previous.Content); i += 2 {
+				if previous.Content[i].Value == prefix {
+					key = previous.Content[i]
+				}
+			}
+		}
+		var value *yaml.Node
+		if patterns := sources[prefix]; len(patterns) == 1 {
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: patterns[0]}
+		} else {
+			value = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for _, pattern := range patterns {
+				value.Content = append(value.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: pattern})
+			}
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node
+}
+
+// The answers given to elz init.
+type Settings struct {
+	SourceLocale string
+	Sources      []string
+	Ignore       []string
+	Translations string
+	// The Go package is not generated if GoOutput is empty.
+	GoOutput  string
+	GoPackage string
+	// The JavaScript modules are not generated if JSOutput is empty.
+	JSOutput string
+	JSModule JSModule
+}
+
+// Return the content of a new configuration file, with comments explaining each section.
+func NewConfigText(settings Settings) []byte {
+	var out bytes.Buffer
+	section := func(comment string, key string, value any) {
+		fmt.Fprintf(&out, "\n# %s\n%s: %s\n", comment, key, yamlValue(value))
+	}
+
+	out.WriteString("# Configuration of Elizalina. Paths are relative to the directory of this file.\n")
+	section("The locale messages are written in first, used when a message is not translated.", "sourceLocale",
+		settings.SourceLocale)
+	section("The files searched for calls to the translate function. Patterns can use *, ** and [...], and patterns "+
+		"starting\n# with ! exclude files. Use a mapping to give a prefix to the keys of some sources.", "sources",
+		settings.Sources)
+	if len(settings.Ignore) > 0 {
+		section("Files left out of every prefix.", "ignore", settings.Ignore)
+	}
+	section("The directory containing the translation files.", "translations", settings.Translations)
+
+	if settings.GoOutput != "" {
+		out.WriteString("\n# The Go package generated by elz release.\ngo:\n")
+		fmt.Fprintf(&out, "  output: %s\n", yamlValue(settings.GoOutput))
+		if settings.GoPackage != "" {
+			fmt.Fprintf(&out, "  package: %s\n", yamlValue(settings.GoPackage))
+		}
+	}
+	if settings.JSOutput != "" {
+		module := "esm"
+		if settings.JSModule == CommonJS {
+			module = "cjs"
+		}
+		out.WriteString("\n# The JavaScript modules generated by elz release.\njs:\n")
+		fmt.Fprintf(&out, "  output: %s\n  module: %s\n", yamlValue(settings.JSOutput), module)
+	}
+	return out.Bytes()
+}
+
+// Write a string or a list of strings as a flow value, with quotes if needed.
+func yamlValue(value any) string {
+	if list, ok := value.([]string); ok && len(list) == 1 {
+		value = list[0]
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		panic(err)
+	}
+	node.Style |= yaml.FlowStyle
+	text, err := yaml.Marshal(node)
+	if err != nil {
+		panic(err)
+	}
+	return string(bytes.TrimSuffix(text, []byte("\n")))
+}
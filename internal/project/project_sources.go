@@ -0,0 +1,82 @@
+package project
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/louisdevie/elizalina2/internal/glob"
+)
+
+// Return the source files of each prefix, sorted and without duplicates. Sources are glob patterns (see package glob)
+// relative to the directory of the configuration file. Patterns matching a directory include all the files inside of
+// it, and negated patterns exclude the files matched by the patterns before them. Files matching the ignore patterns
+// are left out of every prefix. The returned paths start with [Config.Dir].
+func SourceFiles(cfg Config) (map[string][]string, error) {
+	sources, err := cfg.Sources()
+	if err != nil {
+		return nil, err
+	}
+	ignorePatterns, err := cfg.Ignore()
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := glob.NewSet(ignorePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]string, len(sources))
+	for prefix, patterns := range sources {
+		include, err := glob.NewSet(patterns)
+		if err != nil {
+			return nil, err
+		}
+		found, err := resolveFiles(cfg.Dir(), include, ignore)
+		if err != nil {
+			return nil, err
+		}
+		files[prefix] = found
+	}
+	return files, nil
+}
+
+// Walk the base directories of [include] and return the files it matches that are not ignored.
+func resolveFiles(dir string, include glob.Set, ignore glob.Set) ([]string, error) {
+	// ignored directories can be skipped entirely unless some of their files may be included back
+	pruneIgnored := !ignore.HasNegations()
+
+	var found []string
+	for _, base := range include.Bases() {
+		root := filepath.Join(dir, filepath.FromSlash(base))
+		err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if file == root && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if entry.IsDir() {
+				if pruneIgnored && file != root && ignore.Match(rel) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if include.Match(rel) && !ignore.Match(rel) {
+				found = append(found, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(found)
+	return slices.Compact(found), nil
+}
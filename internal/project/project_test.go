@@ -3,6 +3,8 @@ package project_test
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/louisdevie/elizalina2/internal/project"
@@ -45,6 +47,100 @@ func TestParseFullConfig(t *testing.T) {
 	}
 }
 
+func TestParseFormatConfig(t *testing.T) {
+	cfg, err := project.LoadConfigFile("./testdata/full.yml")
+	if err != nil {
+		t.Fatalf("error reading full.yml config file: %s", err)
+	}
+	format := cfg.Format()
+
+	if printWidth, err := format.PrintWidth(); err != nil || printWidth != 80 {
+		t.Fatalf("expected [.format.printWidth] to be 80 but got %v (%v)", printWidth, err)
+	}
+	if inline, err := format.Inline(); err != nil || inline {
+		t.Fatalf("expected [.format.inline] to be false but got %v (%v)", inline, err)
+	}
+	if useTabs, err := format.UseTabs(); err != nil || !useTabs {
+		t.Fatalf("expected [.format.useTabs] to be true but got %v (%v)", useTabs, err)
+	}
+	if maximumSpacing, err := format.MaximumSpacing(); err != nil || maximumSpacing != 1 {
+		t.Fatalf("expected [.format.maximumSpacing] to be 1 but got %v (%v)", maximumSpacing, err)
+	}
+	if sort, err := format.SortMessages(); err != nil || sort != project.Alphabetical {
+		t.Fatalf("expected [.format.sortMessages] to be alphabetical but got %v (%v)", sort, err)
+	}
+
+	cfg, err = project.LoadConfigFile("./testdata/partial.yml")
+	if err != nil {
+		t.Fatalf("error reading partial.yml config file: %s", err)
+	}
+	format = cfg.Format()
+
+	if printWidth, err := format.PrintWidth(); err != nil || printWidth != 80 {
+		t.Fatalf("expected [.format.printWidth] to default to 80 but got %v (%v)", printWidth, err)
+	}
+	if sort, err := format.SortMessages(); err != nil || sort != project.Append {
+		t.Fatalf("expected [.format.sortMessages] to default to append but got %v (%v)", sort, err)
+	}
+}
+
+func TestParseGoConfig(t *testing.T) {
+	cfg, err := project.LoadConfigFile("./testdata/full.yml")
+	if err != nil {
+		t.Fatalf("error reading full.yml config file: %s", err)
+	}
+
+	if sourceLocale, err := cfg.SourceLocale(); err != nil || sourceLocale != "en" {
+		t.Fatalf("expected [.sourceLocale] to be en but got %v (%v)", sourceLocale, err)
+	}
+	if output, err := cfg.Go().Output(); err != nil || output != "internal/i18n" {
+		t.Fatalf("expected [.go.output] to be internal/i18n but got %v (%v)", output, err)
+	}
+	if pkg, err := cfg.Go().Package(); err != nil || pkg != "messages" {
+		t.Fatalf("expected [.go.package] to be messages but got %v (%v)", pkg, err)
+	}
+
+	cfg, err = project.LoadConfigFile("./testdata/partial.yml")
+	if err != nil {
+		t.Fatalf("error reading partial.yml config file: %s", err)
+	}
+	if pkg, err := cfg.Go().Package(); err != nil || pkg != "" {
+		t.Fatalf("expected [.go.package] to be empty but got %v (%v)", pkg, err)
+	}
+}
+
+func TestParseJSConfig(t *testing.T) {
+	cfg, err := project.LoadConfigFile("./testdata/full.yml")
+	if err != nil {
+		t.Fatalf("error reading full.yml config file: %s", err)
+	}
+	js := cfg.JS()
+
+	if output, err := js.Output(); err != nil || output != "dist/i18n" {
+		t.Fatalf("expected [.js.output] to be dist/i18n but got %v (%v)", output, err)
+	}
+	if module, err := js.Module(); err != nil || module != project.ESModule {
+		t.Fatalf("expected [.js.module] to be esm but got %v (%v)", module, err)
+	}
+	if minify, err := js.Minify(); err != nil || !minify {
+		t.Fatalf("expected [.js.minify] to be true but got %v (%v)", minify, err)
+	}
+	if entryPoint, err := js.EntryPoint(); err != nil || entryPoint != "src/locale/index.ts" {
+		t.Fatalf("expected [.js.entryPoint] to be src/locale/index.ts but got %v (%v)", entryPoint, err)
+	}
+	if translateFn, err := js.TranslateFn(); err != nil || translateFn != "__" {
+		t.Fatalf("expected [.js.translateFn] to be __ but got %v (%v)", translateFn, err)
+	}
+
+	cfg, err = project.LoadConfigFile("./testdata/partial.yml")
+	if err != nil {
+		t.Fatalf("error reading partial.yml config file: %s", err)
+	}
+	if minify, err := cfg.JS().Minify(); err != nil || minify {
+		t.Fatalf("expected [.js.minify] to default to false but got %v (%v)", minify, err)
+	}
+}
+
 func TestParsePartialConfig(t *testing.T) {
 	cfg, err := project.LoadConfigFile("./testdata/partial.yml")
 	if err != nil {
@@ -79,6 +175,31 @@ func TestParsePartialConfig(t *testing.T) {
 	}
 }
 
+func TestSourceFiles(t *testing.T) {
+	cfg, err := project.LoadConfigFile("./testdata/sources.yml")
+	if err != nil {
+		t.Fatalf("error reading sources.yml config file: %s", err)
+	}
+
+	files, err := project.SourceFiles(cfg)
+	if err != nil {
+		t.Fatalf("error listing source files: %s", err)
+	}
+	if len(files["a"]) != 1 || files["a"][0] != filepath.Join("testdata", "src", "a", "x.go") {
+		t.Fatalf("expected the sources of a to be [testdata/src/a/x.go] but got %v", files["a"])
+	}
+	if len(files["b"]) != 2 || files["b"][0] != filepath.Join("testdata", "src", "b", "index.ts") ||
+		files["b"][1] != filepath.Join("testdata", "src", "b", "other.ts") {
+		t.Fatalf("expected the sources of b to be [testdata/src/b/index.ts testdata/src/b/other.ts] but got %v",
+			files["b"])
+	}
+	if len(files["c"]) != 2 || files["c"][0] != filepath.Join("testdata", "src", "a", "x.go") ||
+		files["c"][1] != filepath.Join("testdata", "src", "b", "index.ts") {
+		t.Fatalf("expected the sources of c to be [testdata/src/a/x.go testdata/src/b/index.ts] but got %v",
+			files["c"])
+	}
+}
+
 func TestFindConfigFile(t *testing.T) {
   cwd, err := os.Getwd()
   if err != nil {
@@ -110,3 +231,224 @@ func TestFindConfigFile(t *testing.T) {
   }
 }
 
+
+func TestWriteSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), project.StdConfigFileName)
+	original := "# comment\nsources: src # inline\ntranslations: lang\n"
+	if err := os.WriteFile(path, []byte(original), 0666); err != nil {
+		panic(err)
+	}
+
+	err := project.WriteSources(path, map[string][]string{"$": {"src", "!src/ui"}, "ui": {"src/ui"}})
+	if err != nil {
+		t.Fatalf("error writing sources: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	expected := "# comment\nsources: # inline\n  $: [src, '!src/ui']\n  ui: src/ui\ntranslations: lang\n"
+	if string(data) != expected {
+		t.Fatalf("expected the config file to be\n%s\nbut got\n%s", expected, data)
+	}
+
+	cfg, err := project.LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("error reading the written config file: %s", err)
+	}
+	sources, err := cfg.Sources()
+	if err != nil || len(sources) != 2 || len(sources["$"]) != 2 || sources["ui"][0] != "src/ui" {
+		t.Fatalf("expected the written sources to be read back but got %v (%v)", sources, err)
+	}
+}
+
+func TestNewConfigText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), project.StdConfigFileName)
+	text := project.NewConfigText(project.Settings{
+		SourceLocale: "en",
+		Sources:      []string{"src", "!src/generated"},
+		Ignore:       []string{"node_modules"},
+		Translations: "translations",
+		JSOutput:     "src/i18n",
+		JSModule:     project.CommonJS,
+	})
+	if err := os.WriteFile(path, text, 0666); err != nil {
+		panic(err)
+	}
+
+	cfg, err := project.LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("error reading the new config file: %s\n%s", err, text)
+	}
+	sources, err := cfg.Sources()
+	if err != nil || len(sources[project.NoPrefix]) != 2 || sources[project.NoPrefix][1] != "!src/generated" {
+		t.Fatalf("expected the sources to be [src !src/generated] but got %v (%v)", sources, err)
+	}
+	if module, err := cfg.JS().Module(); err != nil || module != project.CommonJS {
+		t.Fatalf("expected the module to be cjs but got %v (%v)", module, err)
+	}
+	if output, err := cfg.Go().Output(); err != nil || output != "" {
+		t.Fatalf("expected no go section but got %q (%v)", output, err)
+	}
+}
+
+func TestUnusedKeys(t *testing.T) {
+	cfg, err := project.LoadConfigFile("./testdata/typos.yml")
+	if err != nil {
+		t.Fatalf("error reading typos.yml config file: %s", err)
+	}
+
+	expected := []string{
+		"2:1: unknown key translation (did you mean translations?)",
+		"4:3: unknown key format.printWidht (did you mean printWidth?)",
+		"8:3: unknown key go.packge (did you mean package?)",
+	}
+	unused := cfg.Unused()
+	if len(unused) != len(expected) {
+		t.Fatalf("expected %d unused keys but got %v", len(expected), unused)
+	}
+	for i, e := range expected {
+		if unused[i].String() != e {
+			t.Fatalf("expected unused key %d to be %q but got %q", i, e, unused[i].String())
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	cfg, err := project.LoadConfigFile("testdata/invalid.yml")
+	if err != nil {
+		t.Fatalf("error reading invalid.yml config file: %s", err)
+	}
+
+	if _, err := cfg.Sources(); err == nil || err.Error() != "testdata/invalid.yml:3:14: sources.b[1]: expected string" {
+		t.Fatalf("expected an error at sources.b[1] but got %v", err)
+	}
+	_, err = cfg.Format().PrintWidth()
+	if err == nil || err.Error() !=
+		"testdata/invalid.yml:5:15: format.printWidth: expected an integer greater than or equal to 1, but got \"wide\"" {
+		t.Fatalf("expected an error at format.printWidth but got %v", err)
+	}
+	_, err = cfg.Go().Package()
+	if err == nil || err.Error() !=
+		"testdata/invalid.yml:7:12: go.package: expected a valid package name, but got \"not valid\"" {
+		t.Fatalf("expected an error at go.package but got %v", err)
+	}
+}
+
+func TestJSONSchemaUpToDate(t *testing.T) {
+	schema, err := project.JSONSchema()
+	if err != nil {
+		t.Fatalf("error generating the schema: %s", err)
+	}
+	committed, err := os.ReadFile("../../docs/elz.schema.json")
+	if err != nil {
+		t.Fatalf("error reading elz.schema.json: %s", err)
+	}
+	if string(schema) != string(committed) {
+		t.Fatal("docs/elz.schema.json is out of date, run 'elz config schema > docs/elz.schema.json'")
+	}
+}
+
+func TestExtends(t *testing.T) {
+	cfg, err := project.LoadConfigFile("testdata/extends/pkg/elz.config.yml")
+	if err != nil {
+		t.Fatalf("error reading the config file: %s", err)
+	}
+
+	if locale, err := cfg.SourceLocale(); err != nil || locale != "en" {
+		t.Fatalf("expected the source locale to be inherited from common.yml but got %q (%v)", locale, err)
+	}
+	if dir, err := cfg.Translations(); err != nil || dir != "lang" {
+		t.Fatalf("expected the translations to be inherited from base.yml but got %q (%v)", dir, err)
+	}
+	format := cfg.Format()
+	if width, err := format.PrintWidth(); err != nil || width != 120 {
+		t.Fatalf("expected the print width to be overridden but got %d (%v)", width, err)
+	}
+	if indent, err := format.Indent(); err != nil || indent != 4 {
+		t.Fatalf("expected the indent to be merged from base.yml but got %d (%v)", indent, err)
+	}
+	if useTabs, err := format.UseTabs(); err != nil || useTabs {
+		t.Fatalf("expected tabs to be disabled by common.yml but got %v (%v)", useTabs, err)
+	}
+	if spacing, err := format.MinimumSpacing(); err != nil || spacing != 3 {
+		t.Fatalf("expected the minimum spacing to be 3 but got %d (%v)", spacing, err)
+	}
+	if sort, err := format.SortMessages(); err != nil || sort != project.Alphabetical {
+		t.Fatalf("expected messages to be sorted alphabetically but got %v (%v)", sort, err)
+	}
+
+	unused := cfg.Unused()
+	if len(unused) != 1 || unused[0].File != "testdata/extends/pkg/elz.config.yml" || unused[0].Line != 7 {
+		t.Fatalf("expected go.outptu to be unused but got %v", unused)
+	}
+
+	cfg, err = project.LoadConfigFile("testdata/extends/broken.yml")
+	if err != nil {
+		t.Fatalf("error reading the config file: %s", err)
+	}
+	_, err = cfg.Format().PrintWidth()
+	if err == nil || err.Error() != "testdata/extends/base.yml:4:15: format.printWidth: "+
+		"expected an integer greater than or equal to 1, but got \"large\"" {
+		t.Fatalf("expected an error in base.yml but got %v", err)
+	}
+
+	_, err = project.LoadConfigFile("testdata/extends/circular.yml")
+	if err == nil || err.Error() != "testdata/extends/pkg/circular.yml:1:10: extends: ../circular.yml is already "+
+		"extended by this file (extends cannot be circular)" {
+		t.Fatalf("expected a circular extends error but got %v", err)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	t.Setenv("ELZ_TEST_LOCALE", "fr")
+	t.Setenv(project.EnvVariable("format.printWidth"), "100")
+	t.Setenv("ELZ_FORMAT_INDENT", "none")
+	override, err := project.ParseOverride("format.printWidth=120")
+	if err != nil {
+		t.Fatalf("error parsing the override: %s", err)
+	}
+	cfg, err := project.LoadConfigFile("testdata/env.yml", override)
+	if err != nil {
+		t.Fatalf("error reading env.yml config file: %s", err)
+	}
+
+	if locale, err := cfg.SourceLocale(); err != nil || locale != "fr" {
+		t.Fatalf("expected the source locale to be read from the environment but got %q (%v)", locale, err)
+	}
+	if dir, err := cfg.Translations(); err != nil || dir != "src/lang" {
+		t.Fatalf("expected the translations to use the default but got %q (%v)", dir, err)
+	}
+	if width, err := cfg.Format().PrintWidth(); err != nil || width != 120 {
+		t.Fatalf("expected the print width to be overridden by the flag but got %d (%v)", width, err)
+	}
+	_, err = cfg.Format().Indent()
+	if err == nil || err.Error() != "$ELZ_FORMAT_INDENT: format.indent: expected an integer greater than or "+
+		"equal to 1, but got \"none\"" {
+		t.Fatalf("expected an error from the environment but got %v", err)
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	path, found := project.FindWorkspaceFile("testdata/workspace/packages")
+	if !found || !strings.HasSuffix(path, filepath.Join("testdata", "workspace", project.StdWorkspaceFileName)) {
+		t.Fatalf("expected to find the workspace file but got %q", path)
+	}
+	if path, found := project.FindWorkspaceFile("testdata/workspace/packages/a/nested"); found {
+		t.Fatalf("expected the configuration of packages/a to hide the workspace but got %q", path)
+	}
+
+	workspace, err := project.LoadWorkspaceFile("testdata/workspace/" + project.StdWorkspaceFileName)
+	if err != nil {
+		t.Fatalf("error reading the workspace file: %s", err)
+	}
+	expected := []string{filepath.Join("testdata", "workspace", "packages", "a", project.StdConfigFileName)}
+	if !slices.Equal(workspace.Projects, expected) {
+		t.Fatalf("expected the projects to be %v but got %v", expected, workspace.Projects)
+	}
+
+	all, err := project.FindProjects("testdata/workspace", nil)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("expected to find 3 projects outside of node_modules but got %v (%v)", all, err)
+	}
+}
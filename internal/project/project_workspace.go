@@ -0,0 +1,116 @@
+package project
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/louisdevie/elizalina2/internal/glob"
+	"github.com/louisdevie/elizalina2/internal/ymlcfg"
+)
+
+const StdWorkspaceFileName = "elz.workspace.yml"
+
+// A group of projects, usually a monorepo.
+type Workspace struct {
+	// The directory containing the workspace file, which relative paths start from.
+	Dir string
+	// The configuration files of the projects, sorted.
+	Projects []string
+}
+
+// The keys of the workspace file.
+type workspaceSection struct {
+	Projects []string `ymlcfg:"projects" doc:"Patterns of the directories searched for projects (all by default)."`
+}
+
+// Find the workspace file in [path] or one of its parents. A workspace is only found if there is no project
+// configuration closer to [path], and a workspace file takes precedence over a configuration in the same directory.
+func FindWorkspaceFile(path string) (file string, found bool) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		panic(err)
+	}
+	for {
+		workspace := filepath.Join(path, StdWorkspaceFileName)
+		if _, err := os.Stat(workspace); err == nil {
+			return workspace, true
+		}
+		if _, err := os.Stat(filepath.Join(path, StdConfigFileName)); err == nil {
+			return "", false
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", false
+		}
+		path = parent
+	}
+}
+
+// Read a workspace file and find its projects.
+func LoadWorkspaceFile(path string) (*Workspace, error) {
+	cv, err := ymlcfg.FromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var section workspaceSection
+	if err := ymlcfg.Bind(cv, &section); err != nil {
+		return nil, err
+	}
+	// unlike in project configurations, unknown keys are errors since there is no command to warn
+	if unused := ymlcfg.Unused(cv); len(unused) > 0 {
+		key := unused[0]
+		msg := "unknown key"
+		if key.Suggestion != "" {
+			msg += " (did you mean " + key.Suggestion + "?)"
+		}
+		return nil, &ymlcfg.Error{File: path, Pos: ymlcfg.Pos{Line: key.Line, Column: key.Column}, Path: key.Path, Msg: msg}
+	}
+
+	dir := filepath.Dir(path)
+	projects, err := FindProjects(dir, section.Projects)
+	return &Workspace{Dir: dir, Projects: projects}, err
+}
+
+// Return the configuration files below [dir], in directories matching [patterns] (see package glob), or all of them
+// if there are no patterns. Hidden directories and node_modules are not searched.
+func FindProjects(dir string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"**"}
+	}
+	include, err := glob.NewSet(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []string
+	err = filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if file != dir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() != StdConfigFileName {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filepath.Dir(file))
+		if err != nil {
+			return err
+		}
+		if include.Match(filepath.ToSlash(rel)) {
+			projects = append(projects, file)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return projects, err
+}
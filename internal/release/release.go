@@ -0,0 +1,200 @@
+// Source code generation from translations.
+package release
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+// The messages of a project, as they will be released.
+type Bundle struct {
+	SourceLocale string
+	// All the locales including the source locale, sorted.
+	Locales []string
+	// The messages of the source locale, sorted by prefix and key.
+	Messages []*Entry
+	// Problems that do not prevent the release, such as translations of messages that are not in the source locale.
+	Warnings []error
+}
+
+// A message and its translations.
+type Entry struct {
+	Prefix string
+	Key    string
+	// The parameters of the message in the source locale.
+	Params []lang.Param
+	// The translations that are not empty, by locale. This includes the source locale.
+	Translations map[string]*lang.Message
+}
+
+// Return the key of the entry, qualified with its prefix.
+func (entry *Entry) ID() string {
+	if entry.Prefix == project.NoPrefix {
+		return entry.Key
+	} else {
+		return entry.Prefix + "." + entry.Key
+	}
+}
+
+// Gather the messages of a catalog and check that their translations are consistent with the source locale.
+func NewBundle(catalog *lang.Catalog, sourceLocale string) (*Bundle, error) {
+	if sourceLocale == "" {
+		return nil, errors.New("the source locale is not set")
+	}
+	bundle := &Bundle{SourceLocale: sourceLocale, Locales: catalog.Locales()}
+	if !slices.Contains(bundle.Locales, sourceLocale) {
+		return nil, fmt.Errorf("there are no translation files for the source locale %q", sourceLocale)
+	}
+
+	var errs []error
+	entries := make(map[[2]string]*Entry)
+	for _, file := range catalog.Files {
+		if file.Locale != sourceLocale {
+			continue
+		}
+		for _, msg := range file.Messages {
+			entry := &Entry{
+				Prefix:       file.Prefix,
+				Key:          msg.Key,
+				Params:       msg.Params(),
+				Translations: make(map[string]*lang.Message),
+			}
+			if !msg.IsEmpty() {
+				errs = append(errs, checkSelectors(file, msg.Text)...)
+				entry.Translations[sourceLocale] = msg
+			}
+			entries[[2]string{file.Prefix, msg.Key}] = entry
+			bundle.Messages = append(bundle.Messages, entry)
+		}
+	}
+
+	for _, file := range catalog.Files {
+		if file.Locale == sourceLocale {
+			continue
+		}
+		for _, msg := range file.Messages {
+			entry, ok := entries[[2]string{file.Prefix, msg.Key}]
+			if !ok {
+				bundle.Warnings = append(bundle.Warnings, fmt.Errorf(
+					"%s:%s: message %q does not exist in the source locale and will be ignored",
+					file.Path, msg.Pos, msg.Key))
+				continue
+			}
+			if msg.IsEmpty() {
+				continue
+			}
+			errs = append(errs, checkParams(file, msg, entry)...)
+			errs = append(errs, checkSelectors(file, msg.Text)...)
+			entry.Translations[file.Locale] = msg
+		}
+	}
+
+	slices.SortFunc(bundle.Messages, func(a, b *Entry) int {
+		if a.Prefix != b.Prefix {
+			return strings.Compare(a.Prefix, b.Prefix)
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return bundle, errors.Join(errs...)
+}
+
+// Check that a translation only uses the parameters of the source message. Placeholders without a type in the
+// translation take the type of the source.
+func checkParams(file *lang.File, msg *lang.Message, entry *Entry) (errs []error) {
+	for _, param := range msg.DeclaredParams() {
+		i := slices.IndexFunc(entry.Params, func(p lang.Param) bool { return p.Name == param.Name })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("%s:%s: {%s} is not a parameter of %q in the source locale",
+				file.Path, msg.Pos, param.Name, entry.ID()))
+		} else if param.Type != "" && entry.Params[i].Type != param.Type {
+			errs = append(errs, fmt.Errorf("%s:%s: {%s} is a %s in the source locale but is used as a %s",
+				file.Path, msg.Pos, param.Name, entry.Params[i].Type, param.Type))
+		}
+	}
+	return errs
+}
+
+// Check that the number selectors of the conditionals in a text fit in 64 bits and that no two of them select the
+// same number, like 1 and 01.
+func checkSelectors(file *lang.File, text lang.Text) (errs []error) {
+	for _, seg := range text {
+		cond, ok := seg.(*lang.Conditional)
+		if !ok {
+			continue
+		}
+		numbers := make(map[string]string)
+		for _, c := range cond.Cases {
+			if c.Selector.Kind == lang.NumberSelector {
+				n, err := numberLiteral(c.Selector.Value)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s:%s: %s is out of range for {%s}",
+						file.Path, c.Selector.Pos, c.Selector.Value, cond.Name))
+				} else if other, ok := numbers[n]; ok {
+					errs = append(errs, fmt.Errorf("%s:%s: cases %s and %s of {%s} select the same number",
+						file.Path, c.Selector.Pos, other, c.Selector.Value, cond.Name))
+				} else {
+					numbers[n] = c.Selector.Value
+				}
+			}
+			errs = append(errs, checkSelectors(file, c.Text)...)
+		}
+	}
+	return errs
+}
+
+// Return the canonical form of a number selector, without leading zeros, so that it can be used as a literal in the
+// generated code.
+func numberLiteral(value string) (string, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(n, 10), nil
+}
+
+// Turn a message key such as "inbox.unread-count" into an identifier such as "InboxUnreadCount". This also works for
+// locales, "fr-CA" becoming "FrCA".
+func exportedName(key string) string {
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+		first, size := utf8.DecodeRuneInString(part)
+		name.WriteRune(unicode.ToUpper(first))
+		name.WriteString(part[size:])
+	}
+	return name.String()
+}
+
+// Check that no two messages are given the same name by [messageName], and that no two locales are given the same
+// name by any of [localeNames]. [messageName] may be nil if messages are not named.
+func checkNames(bundle *Bundle, messageName func(*Entry) string, localeNames ...func(string) string) error {
+	var errs []error
+	if messageName != nil {
+		names := make(map[string]*Entry)
+		for _, entry := range bundle.Messages {
+			n := messageName(entry)
+			if other, ok := names[n]; ok {
+				errs = append(errs, fmt.Errorf("messages %q and %q would both be named %s", other.ID(), entry.ID(), n))
+			}
+			names[n] = entry
+		}
+	}
+	for _, localeName := range localeNames {
+		names := make(map[string]string)
+		for _, locale := range bundle.Locales {
+			n := localeName(locale)
+			if other, ok := names[n]; ok {
+				errs = append(errs, fmt.Errorf("locales %q and %q would both be named %s", other, locale, n))
+			}
+			names[n] = locale
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,276 @@
+package release
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/louisdevie/elizalina2/internal/lang"
+)
+
+// The first line of every generated file.
+const GeneratedHeader = "// Code generated by elz; DO NOT EDIT."
+
+type GoOptions struct {
+	Package string
+}
+
+// Generate a Go package with a Messages interface implemented once per locale. Locales that are missing a message
+// fall back on the source locale. The content of each file is returned by file name.
+func GenerateGo(bundle *Bundle, opts GoOptions) (map[string][]byte, error) {
+	if err := checkNames(bundle, goMethodName, goTypeName, goFileName); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	var err error
+	files["messages.go"], err = generateGoIndex(bundle, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, locale := range bundle.Locales {
+		files[goFileName(locale)], err = generateGoLocale(bundle, opts, locale)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func goMethodName(entry *Entry) string {
+	return exportedName(entry.ID())
+}
+
+func goTypeName(locale string) string {
+	return "locale" + exportedName(locale)
+}
+
+func goFileName(locale string) string {
+	return "locale_" + strings.ToLower(strings.ReplaceAll(locale, "-", "_")) + ".go"
+}
+
+// Names that parameters cannot take because they are used by the generated code.
+var goReservedNames = []string{"b", "strconv", "strings"}
+
+func goParamName(name string) string {
+	if token.IsKeyword(name) || slices.Contains(goReservedNames, name) {
+		return name + "_"
+	}
+	return name
+}
+
+func goType(typ lang.Type) string {
+	switch typ {
+	case lang.Int:
+		return "int"
+	case lang.Float:
+		return "float64"
+	case lang.Bool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+func goSignature(entry *Entry) string {
+	params := make([]string, len(entry.Params))
+	for i, param := range entry.Params {
+		params[i] = goParamName(param.Name) + " " + goType(param.Type)
+	}
+	return goMethodName(entry) + "(" + strings.Join(params, ", ") + ") string"
+}
+
+// Writes Go source code, which is formatted afterwards.
+type goWriter struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+func (w *goWriter) line(format string, args ...any) {
+	fmt.Fprintf(&w.buf, format, args...)
+	w.buf.WriteByte('\n')
+}
+
+// Return the source of the file, with its header, package clause and imports.
+func (w *goWriter) finish(pkg string) ([]byte, error) {
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "%s\n\npackage %s\n\n", GeneratedHeader, pkg)
+	if len(w.imports) > 0 {
+		file.WriteString("import (\n")
+		for _, imp := range slices.Sorted(maps.Keys(w.imports)) {
+			fmt.Fprintf(&file, "%q\n", imp)
+		}
+		file.WriteString(")\n\n")
+	}
+	file.Write(w.buf.Bytes())
+
+	src, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go code: %w", err)
+	}
+	return src, nil
+}
+
+func generateGoIndex(bundle *Bundle, opts GoOptions) ([]byte, error) {
+	w := &goWriter{imports: map[string]bool{"strings": true}}
+
+	w.line("// The locale messages are written in, used when a message is not translated.")
+	w.line("const SourceLocale = %q", bundle.SourceLocale)
+	w.line("")
+	w.line("// The translated messages of a locale.")
+	w.line("type Messages interface {")
+	for _, entry := range bundle.Messages {
+		if msg, ok := entry.Translations[bundle.SourceLocale]; ok {
+			w.line("// %s: %s", entry.ID(), strings.ReplaceAll(msg.Text.String(), "\n", " "))
+		}
+		w.line("%s", goSignature(entry))
+	}
+	w.line("}")
+	w.line("")
+	w.line("var locales = map[string]Messages{")
+	for _, locale := range bundle.Locales {
+		w.line("%q: %s{},", locale, goTypeName(locale))
+	}
+	w.line("}")
+	w.line("")
+	w.line("// Return the available locales, sorted.")
+	w.line("func Locales() []string {")
+	quoted := make([]string, len(bundle.Locales))
+	for i, locale := range bundle.Locales {
+		quoted[i] = strconv.Quote(locale)
+	}
+	w.line("return []string{%s}", strings.Join(quoted, ", "))
+	w.line("}")
+	w.line("")
+	w.line("// Return the messages of [locale]. If it is not available, less specific locales are tried before")
+	w.line("// falling back on the source locale, so that \"fr-CA\" may give the messages of \"fr\".")
+	w.line("func For(locale string) Messages {")
+	w.line("for locale != \"\" {")
+	w.line("if messages, ok := locales[locale]; ok {")
+	w.line("return messages")
+	w.line("}")
+	w.line("i := strings.LastIndexAny(locale, \"-_\")")
+	w.line("if i < 0 {")
+	w.line("break")
+	w.line("}")
+	w.line("locale = locale[:i]")
+	w.line("}")
+	w.line("return locales[SourceLocale]")
+	w.line("}")
+
+	return w.finish(opts.Package)
+}
+
+func generateGoLocale(bundle *Bundle, opts GoOptions, locale string) ([]byte, error) {
+	w := &goWriter{imports: make(map[string]bool)}
+	typeName := goTypeName(locale)
+
+	if locale == bundle.SourceLocale {
+		w.line("type %s struct{}", typeName)
+	} else {
+		w.line("// Messages that are not translated are inherited from the source locale.")
+		w.line("type %s struct{ %s }", typeName, goTypeName(bundle.SourceLocale))
+	}
+
+	for _, entry := range bundle.Messages {
+		msg, ok := entry.Translations[locale]
+		if !ok && locale != bundle.SourceLocale {
+			continue
+		}
+		w.line("")
+		w.line("func (%s) %s {", typeName, goSignature(entry))
+		if !ok {
+			// the message has no text in the source locale either
+			w.line("return %q", entry.ID())
+		} else if hasConditionals(msg.Text) {
+			w.imports["strings"] = true
+			w.line("var b strings.Builder")
+			w.writeText(msg.Text, entry.Params)
+			w.line("return b.String()")
+		} else {
+			w.line("return %s", w.concatenation(msg.Text, entry.Params))
+		}
+		w.line("}")
+	}
+
+	return w.finish(opts.Package)
+}
+
+func hasConditionals(text lang.Text) bool {
+	return slices.ContainsFunc(text, func(seg lang.Segment) bool {
+		_, ok := seg.(*lang.Conditional)
+		return ok
+	})
+}
+
+// Return the expression converting a parameter to a string.
+func (w *goWriter) toString(name string, params []lang.Param) string {
+	i := slices.IndexFunc(params, func(p lang.Param) bool { return p.Name == name })
+	ident := goParamName(name)
+	switch params[i].Type {
+	case lang.Int:
+		w.imports["strconv"] = true
+		return "strconv.Itoa(" + ident + ")"
+	case lang.Float:
+		w.imports["strconv"] = true
+		return "strconv.FormatFloat(" + ident + ", 'g', -1, 64)"
+	case lang.Bool:
+		w.imports["strconv"] = true
+		return "strconv.FormatBool(" + ident + ")"
+	default:
+		return ident
+	}
+}
+
+// Return an expression concatenating a text without conditionals.
+func (w *goWriter) concatenation(text lang.Text, params []lang.Param) string {
+	if len(text) == 0 {
+		return `""`
+	}
+	parts := make([]string, len(text))
+	for i, seg := range text {
+		switch seg := seg.(type) {
+		case *lang.Literal:
+			parts[i] = strconv.Quote(seg.Value)
+		case *lang.Placeholder:
+			parts[i] = w.toString(seg.Name, params)
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+// Write statements appending a text to a strings.Builder named b.
+func (w *goWriter) writeText(text lang.Text, params []lang.Param) {
+	for _, seg := range text {
+		switch seg := seg.(type) {
+		case *lang.Literal:
+			w.line("b.WriteString(%s)", strconv.Quote(seg.Value))
+		case *lang.Placeholder:
+			w.line("b.WriteString(%s)", w.toString(seg.Name, params))
+		case *lang.Conditional:
+			i := slices.IndexFunc(params, func(p lang.Param) bool { return p.Name == seg.Name })
+			w.line("switch %s {", goParamName(seg.Name))
+			for _, c := range seg.Cases {
+				switch {
+				case c.Selector.Kind == lang.DefaultSelector:
+					w.line("default:")
+				case params[i].Type == lang.String:
+					w.line("case %s:", strconv.Quote(c.Selector.Value))
+				case c.Selector.Kind == lang.NumberSelector:
+					// the selectors were checked when the bundle was made
+					n, _ := numberLiteral(c.Selector.Value)
+					w.line("case %s:", n)
+				default:
+					w.line("case %s:", c.Selector.Value)
+				}
+				w.writeText(c.Text, params)
+			}
+			w.line("}")
+		}
+	}
+}
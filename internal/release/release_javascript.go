@@ -0,0 +1,429 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+type JSOptions struct {
+	Module      project.JSModule
+	Minify      bool
+	TranslateFn string
+}
+
+var jsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Names exported by the index module, that the translate function cannot take.
+var jsExportedNames = []string{"sourceLocale", "locales", "setLocale", "getLocale"}
+
+// Words that cannot be used as parameter names.
+var jsReservedWords = []string{
+	"arguments", "await", "break", "case", "catch", "class", "const", "continue", "debugger", "default", "delete",
+	"do", "else", "enum", "eval", "export", "extends", "false", "finally", "for", "function", "if", "implements",
+	"import", "in", "instanceof", "interface", "let", "new", "null", "package", "private", "protected", "public",
+	"return", "static", "super", "switch", "this", "throw", "true", "try", "typeof", "var", "void", "while", "with",
+	"yield",
+}
+
+// Generate JavaScript modules and their TypeScript declarations. The index module exports the translate function
+// for messages without a prefix, and each prefix has its own module exporting a translate function for its messages.
+// The content of each file is returned by its path relative to the output directory.
+func GenerateJS(bundle *Bundle, opts JSOptions) (map[string][]byte, error) {
+	if !jsIdentifier.MatchString(opts.TranslateFn) || slices.Contains(jsExportedNames, opts.TranslateFn) ||
+		slices.Contains(jsReservedWords, opts.TranslateFn) {
+		return nil, fmt.Errorf("%q cannot be used as the name of the translate function", opts.TranslateFn)
+	}
+
+	if err := checkNames(bundle, nil, jsLocaleName); err != nil {
+		return nil, err
+	}
+
+	var (
+		errs     []error
+		prefixes []string
+	)
+	for _, entry := range bundle.Messages {
+		if entry.Prefix != project.NoPrefix && !slices.Contains(prefixes, entry.Prefix) {
+			if !jsIdentifier.MatchString(entry.Prefix) || entry.Prefix == "index" {
+				errs = append(errs, fmt.Errorf("prefix %q cannot be used as a module name", entry.Prefix))
+			}
+			prefixes = append(prefixes, entry.Prefix)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	files := make(map[string][]byte)
+	moduleType := "module"
+	if opts.Module == project.CommonJS {
+		moduleType = "commonjs"
+	}
+	files["package.json"] = []byte(fmt.Sprintf("{\n  \"type\": %q\n}\n", moduleType))
+
+	files["index.js"] = generateJSIndex(bundle, opts)
+	files["index.d.ts"] = generateDTS(bundle, opts, project.NoPrefix)
+	for _, prefix := range prefixes {
+		files[prefix+".js"] = generateJSPrefix(opts, prefix)
+		files[prefix+".d.ts"] = generateDTS(bundle, opts, prefix)
+	}
+	for _, locale := range bundle.Locales {
+		files["locales/"+locale+".js"] = generateJSLocale(bundle, opts, locale)
+	}
+	return files, nil
+}
+
+// Generate a module that re-exports the index module, so that it can be imported from the sources.
+func GenerateJSEntryPoint(opts JSOptions, importPath string) []byte {
+	var w jsWriter
+	if opts.Module == project.CommonJS {
+		w.line("module.exports = require(%s);", jsString(importPath))
+	} else {
+		w.line("export * from %s;", jsString(importPath))
+	}
+	return w.finish(false)
+}
+
+// Writes JavaScript code, which can be minified afterwards.
+type jsWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *jsWriter) line(format string, args ...any) {
+	fmt.Fprintf(&w.buf, format, args...)
+	w.buf.WriteByte('\n')
+}
+
+// Return the source of the file with its header.
+func (w *jsWriter) finish(minify bool) []byte {
+	if minify {
+		return []byte(GeneratedHeader + "\n" + minifyJS(w.buf.String()) + "\n")
+	}
+	return []byte(GeneratedHeader + "\n\n" + w.buf.String())
+}
+
+func (w *jsWriter) imports(opts JSOptions, name string, path string) {
+	if opts.Module == project.CommonJS {
+		w.line("const %s = require(%s);", name, jsString(path))
+	} else {
+		w.line("import %s from %s;", name, jsString(path))
+	}
+}
+
+func (w *jsWriter) exports(opts JSOptions, names ...string) {
+	if opts.Module == project.CommonJS {
+		w.line("module.exports = { %s };", strings.Join(names, ", "))
+	} else {
+		w.line("export { %s };", strings.Join(names, ", "))
+	}
+}
+
+func jsLocaleName(locale string) string {
+	return "locale" + exportedName(locale)
+}
+
+func jsParamName(name string) string {
+	if slices.Contains(jsReservedWords, name) {
+		return name + "_"
+	}
+	return name
+}
+
+// Quote a string as a JavaScript literal.
+func jsString(value string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Make a text safe to put inside a block comment.
+func jsComment(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "*/", "*\\/"), "\n", " ")
+}
+
+func generateJSIndex(bundle *Bundle, opts JSOptions) []byte {
+	var w jsWriter
+	if opts.Module == project.CommonJS {
+		w.line(`"use strict";`)
+	}
+	for _, locale := range bundle.Locales {
+		w.imports(opts, jsLocaleName(locale), "./locales/"+locale+".js")
+	}
+	w.line("")
+	w.line("const messages = {")
+	source := jsLocaleName(bundle.SourceLocale)
+	for _, locale := range bundle.Locales {
+		if locale == bundle.SourceLocale {
+			w.line("\t%s: %s,", jsString(locale), source)
+		} else {
+			w.line("\t%s: Object.assign({}, %s, %s),", jsString(locale), source, jsLocaleName(locale))
+		}
+	}
+	w.line("};")
+	w.line("")
+	w.line("const has = (object, key) => Object.prototype.hasOwnProperty.call(object, key);")
+	w.line("")
+	w.line("/** The locale messages are written in, used when a message is not translated. */")
+	w.line("const sourceLocale = %s;", jsString(bundle.SourceLocale))
+	w.line("")
+	w.line("/** The available locales, sorted. */")
+	quoted := make([]string, len(bundle.Locales))
+	for i, locale := range bundle.Locales {
+		quoted[i] = jsString(locale)
+	}
+	w.line("const locales = [%s];", strings.Join(quoted, ", "))
+	w.line("")
+	w.line("let currentLocale = sourceLocale;")
+	w.line("")
+	w.line("/**")
+	w.line(" * Change the locale of the messages. If it is not available, less specific locales are tried before")
+	w.line(" * falling back on the source locale, so that \"fr-CA\" may select \"fr\". Return the selected locale.")
+	w.line(" */")
+	w.line("function setLocale(locale) {")
+	w.line("\twhile (locale) {")
+	w.line("\t\tif (has(messages, locale)) {")
+	w.line("\t\t\tcurrentLocale = locale;")
+	w.line("\t\t\treturn locale;")
+	w.line("\t\t}")
+	w.line("\t\tconst i = Math.max(locale.lastIndexOf(\"-\"), locale.lastIndexOf(\"_\"));")
+	w.line("\t\tlocale = i < 0 ? \"\" : locale.slice(0, i);")
+	w.line("\t}")
+	w.line("\tcurrentLocale = sourceLocale;")
+	w.line("\treturn sourceLocale;")
+	w.line("}")
+	w.line("")
+	w.line("/** Return the current locale. */")
+	w.line("function getLocale() {")
+	w.line("\treturn currentLocale;")
+	w.line("}")
+	w.line("")
+	w.line("/** Translate a message in the current locale. Unknown messages are returned as is. */")
+	w.line("function %s(key, ...args) {", opts.TranslateFn)
+	w.line("\tconst current = messages[currentLocale];")
+	w.line("\treturn has(current, key) ? current[key](...args) : key;")
+	w.line("}")
+	w.line("")
+	w.exports(opts, append(slices.Clone(jsExportedNames), opts.TranslateFn)...)
+	return w.finish(opts.Minify)
+}
+
+func generateJSPrefix(opts JSOptions, prefix string) []byte {
+	var w jsWriter
+	if opts.Module == project.CommonJS {
+		w.line(`"use strict";`)
+		w.line("const { %s: translate } = require(\"./index.js\");", opts.TranslateFn)
+	} else {
+		w.line("import { %s as translate } from \"./index.js\";", opts.TranslateFn)
+	}
+	w.line("")
+	w.line("/** Translate a message with the prefix %s in the current locale. */", jsComment(prefix))
+	w.line("function %s(key, ...args) {", opts.TranslateFn)
+	w.line("\treturn translate(%s + key, ...args);", jsString(prefix+"."))
+	w.line("}")
+	w.line("")
+	w.exports(opts, opts.TranslateFn)
+	return w.finish(opts.Minify)
+}
+
+func generateJSLocale(bundle *Bundle, opts JSOptions, locale string) []byte {
+	var w jsWriter
+	if opts.Module == project.CommonJS {
+		w.line("module.exports = {")
+	} else {
+		w.line("export default {")
+	}
+	for _, entry := range bundle.Messages {
+		msg, ok := entry.Translations[locale]
+		if !ok {
+			continue
+		}
+		params := make([]string, len(entry.Params))
+		for i, param := range entry.Params {
+			params[i] = jsParamName(param.Name)
+		}
+		w.line("\t%s: (%s) => %s,", jsString(entry.ID()), strings.Join(params, ", "), jsExpression(msg.Text, true))
+	}
+	w.line("};")
+	return w.finish(opts.Minify)
+}
+
+// Return an expression evaluating to a text. If [standalone] is false, the expression may need parentheses.
+func jsExpression(text lang.Text, standalone bool) string {
+	var parts []string
+	for _, seg := range text {
+		switch seg := seg.(type) {
+		case *lang.Literal:
+			parts = append(parts, jsString(seg.Value))
+		case *lang.Placeholder:
+			parts = append(parts, jsParamName(seg.Name))
+		case *lang.Conditional:
+			parts = append(parts, "("+jsConditional(seg)+")")
+		}
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	if _, isLiteral := text[0].(*lang.Literal); !isLiteral {
+		// make sure the parts are concatenated as strings
+		parts = append([]string{`""`}, parts...)
+	}
+	if len(parts) == 1 || standalone {
+		return strings.Join(parts, " + ")
+	}
+	return "(" + strings.Join(parts, " + ") + ")"
+}
+
+func jsConditional(cond *lang.Conditional) string {
+	var (
+		chain    strings.Builder
+		fallback *lang.Case
+		typ      = cond.ResolvedType()
+		cases    []*lang.Case
+	)
+	for _, c := range cond.Cases {
+		if c.Selector.Kind == lang.DefaultSelector {
+			fallback = c
+		} else {
+			cases = append(cases, c)
+		}
+	}
+	if fallback == nil {
+		// conditionals on booleans can omit the default case
+		fallback = cases[len(cases)-1]
+		cases = cases[:len(cases)-1]
+	}
+
+	for _, c := range cases {
+		value := c.Selector.Value
+		if typ == lang.String {
+			value = jsString(value)
+		} else if c.Selector.Kind == lang.NumberSelector {
+			// the selectors were checked when the bundle was made
+			value, _ = numberLiteral(value)
+		}
+		fmt.Fprintf(&chain, "%s === %s ? %s : ", jsParamName(cond.Name), value, jsExpression(c.Text, false))
+	}
+	chain.WriteString(jsExpression(fallback.Text, false))
+	return chain.String()
+}
+
+func tsType(typ lang.Type) string {
+	switch typ {
+	case lang.Int, lang.Float:
+		return "number"
+	case lang.Bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+func generateDTS(bundle *Bundle, opts JSOptions, prefix string) []byte {
+	var w jsWriter
+	if prefix == project.NoPrefix {
+		quoted := make([]string, len(bundle.Locales))
+		for i, locale := range bundle.Locales {
+			quoted[i] = jsString(locale)
+		}
+		w.line("/** The available locales. */")
+		w.line("export type Locale = %s;", strings.Join(quoted, " | "))
+		w.line("")
+	}
+
+	w.line("/** The parameters of each message. */")
+	w.line("export interface Messages {")
+	for _, entry := range bundle.Messages {
+		if entry.Prefix != prefix {
+			continue
+		}
+		if msg, ok := entry.Translations[bundle.SourceLocale]; ok {
+			w.line("\t/** %s */", jsComment(msg.Text.String()))
+		}
+		params := make([]string, len(entry.Params))
+		for i, param := range entry.Params {
+			params[i] = jsParamName(param.Name) + ": " + tsType(param.Type)
+		}
+		w.line("\t%s: [%s];", jsString(entry.Key), strings.Join(params, ", "))
+	}
+	w.line("}")
+	w.line("")
+
+	if prefix == project.NoPrefix {
+		w.line("/** The locale messages are written in, used when a message is not translated. */")
+		w.line("export declare const sourceLocale: Locale;")
+		w.line("/** The available locales, sorted. */")
+		w.line("export declare const locales: readonly Locale[];")
+		w.line("/**")
+		w.line(" * Change the locale of the messages. If it is not available, less specific locales are tried before")
+		w.line(" * falling back on the source locale, so that \"fr-CA\" may select \"fr\". Return the selected locale.")
+		w.line(" */")
+		w.line("export declare function setLocale(locale: string): Locale;")
+		w.line("/** Return the current locale. */")
+		w.line("export declare function getLocale(): Locale;")
+	}
+	w.line("/** Translate a message in the current locale. */")
+	w.line("export declare function %s<K extends keyof Messages>(key: K, ...args: Messages[K]): string;",
+		opts.TranslateFn)
+	return w.finish(false)
+}
+
+func isJSIdentRune(b byte) bool {
+	return b == '_' || b == '$' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b >= 0x80
+}
+
+// Remove comments and whitespace from generated code. This only handles what the generator produces: statements
+// always end with a semicolon, and there are no regular expressions or template literals.
+func minifyJS(src string) string {
+	var (
+		out     strings.Builder
+		spacing bool
+	)
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if spacing && out.Len() > 0 && isJSIdentRune(out.String()[out.Len()-1]) {
+				out.WriteByte(' ')
+			}
+			out.WriteString(src[i : end+1])
+			i = end
+			spacing = false
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			i += end + 3
+			spacing = true
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+			spacing = true
+		case c == ' ' || c == '\t' || c == '\n':
+			spacing = true
+		default:
+			if spacing && out.Len() > 0 && isJSIdentRune(out.String()[out.Len()-1]) && isJSIdentRune(c) {
+				out.WriteByte(' ')
+			}
+			out.WriteByte(c)
+			spacing = false
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
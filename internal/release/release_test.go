@@ -0,0 +1,270 @@
+package release_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
+	"github.com/louisdevie/elizalina2/internal/release"
+)
+
+func loadBundle(t *testing.T, dir string) (*release.Bundle, error) {
+	catalog, err := lang.LoadDir(dir)
+	if err != nil {
+		t.Fatalf("error loading %s: %s", dir, err)
+	}
+	return release.NewBundle(catalog, "en")
+}
+
+func TestNewBundle(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/lang")
+	if err != nil {
+		t.Fatalf("error creating bundle: %s", err)
+	}
+
+	if len(bundle.Messages) != 6 {
+		t.Fatalf("expected 6 messages but got %d", len(bundle.Messages))
+	}
+	if id := bundle.Messages[5].ID(); id != "ui.title" {
+		t.Fatalf("expected the last message to be ui.title but got %s", id)
+	}
+	if len(bundle.Warnings) != 1 || !strings.Contains(bundle.Warnings[0].Error(), `"obsolete"`) {
+		t.Fatalf("expected a warning about the obsolete message but got %v", bundle.Warnings)
+	}
+
+	for _, entry := range bundle.Messages {
+		if entry.Key == "untranslated" {
+			if _, ok := entry.Translations["fr"]; ok {
+				t.Fatal("expected empty translations to be left out")
+			}
+		}
+	}
+}
+
+func TestInconsistentParams(t *testing.T) {
+	_, err := loadBundle(t, "testdata/invalid")
+	if err == nil {
+		t.Fatal("expected inconsistent parameters to be reported")
+	}
+	expected := []string{
+		"testdata/invalid/fr.elz:1:1: {nom} is not a parameter of \"greeting\" in the source locale",
+		"testdata/invalid/fr.elz:2:1: {n} is a int in the source locale but is used as a float",
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 2 || lines[0] != expected[0] || lines[1] != expected[1] {
+		t.Fatalf("expected errors %q but got %q", expected, lines)
+	}
+}
+
+func TestUntypedParams(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/untyped")
+	if err != nil {
+		t.Fatalf("expected untyped placeholders to take the type of the source but got: %s", err)
+	}
+	for _, entry := range bundle.Messages {
+		if entry.Translations["fr"] == nil {
+			t.Fatalf("expected %q to be translated in fr", entry.ID())
+		}
+	}
+}
+
+func TestDuplicateSelectors(t *testing.T) {
+	_, err := loadBundle(t, "testdata/duplicates")
+	expected := "testdata/duplicates/fr.elz:1:27: cases 1 and 01 of {n} select the same number"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q but got %v", expected, err)
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/lang")
+	if err != nil {
+		t.Fatalf("error creating bundle: %s", err)
+	}
+	files, err := release.GenerateGo(bundle, release.GoOptions{Package: "messages"})
+	if err != nil {
+		t.Fatalf("error generating Go code: %s", err)
+	}
+
+	for _, name := range []string{"messages.go", "locale_en.go", "locale_fr.go"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("expected %s to be generated", name)
+		}
+	}
+
+	// the generated package must compile
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for name, src := range files {
+		if !strings.HasPrefix(string(src), release.GeneratedHeader) {
+			t.Fatalf("expected %s to start with the generated code header", name)
+		}
+		file, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatalf("error parsing generated %s: %s", name, err)
+		}
+		parsed = append(parsed, file)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("messages", fset, parsed, nil)
+	if err != nil {
+		t.Fatalf("generated code does not compile: %s", err)
+	}
+
+	iface := pkg.Scope().Lookup("Messages").Type().Underlying().(*types.Interface)
+	expected := map[string]string{
+		"Greeting":     "func(name string) string",
+		"InboxCount":   "func(count int) string",
+		"Price":        "func(amount float64) string",
+		"Todo":         "func() string",
+		"UiTitle":      "func(type_ string) string",
+		"Untranslated": "func() string",
+	}
+	if iface.NumMethods() != len(expected) {
+		t.Fatalf("expected Messages to have %d methods but got %d", len(expected), iface.NumMethods())
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		method := iface.Method(i)
+		if signature := method.Type().String(); expected[method.Name()] != signature {
+			t.Fatalf("expected %s to be %s but got %s", method.Name(), expected[method.Name()], signature)
+		}
+	}
+
+	// untranslated messages are inherited from the source locale
+	fr := string(files["locale_fr.go"])
+	if !strings.Contains(fr, "type localeFr struct{ localeEn }") || strings.Contains(fr, "Untranslated()") {
+		t.Fatalf("expected localeFr to inherit untranslated messages from localeEn but got:\n%s", fr)
+	}
+}
+
+func TestGenerateGoSelectors(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/selectors")
+	if err != nil {
+		t.Fatalf("error creating bundle: %s", err)
+	}
+	files, err := release.GenerateGo(bundle, release.GoOptions{Package: "messages"})
+	if err != nil {
+		t.Fatalf("error generating Go code: %s", err)
+	}
+	en := string(files["locale_en.go"])
+	if !strings.Contains(en, "case 10:") || !strings.Contains(en, "case -1:") {
+		t.Fatalf("expected number selectors without leading zeros but got:\n%s", en)
+	}
+}
+
+func TestLocaleNameCollisions(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/locales")
+	if err != nil {
+		t.Fatalf("error creating bundle: %s", err)
+	}
+
+	_, err = release.GenerateGo(bundle, release.GoOptions{Package: "messages"})
+	expected := "locales \"fr-CA\" and \"fr_CA\" would both be named localeFrCA\n" +
+		"locales \"fr-CA\" and \"fr_CA\" would both be named locale_fr_ca.go"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q but got %v", expected, err)
+	}
+
+	_, err = release.GenerateJS(bundle, release.JSOptions{Module: project.ESModule, TranslateFn: "__"})
+	expected = "locales \"fr-CA\" and \"fr_CA\" would both be named localeFrCA"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q but got %v", expected, err)
+	}
+}
+
+func TestGenerateJS(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/lang")
+	if err != nil {
+		t.Fatalf("error creating bundle: %s", err)
+	}
+	files, err := release.GenerateJS(bundle, release.JSOptions{Module: project.ESModule, TranslateFn: "__"})
+	if err != nil {
+		t.Fatalf("error generating JavaScript code: %s", err)
+	}
+
+	expected := []string{
+		"package.json", "index.js", "index.d.ts", "ui.js", "ui.d.ts", "locales/en.js", "locales/fr.js",
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d files to be generated but got %d", len(expected), len(files))
+	}
+	for _, name := range expected {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("expected %s to be generated", name)
+		}
+	}
+
+	assertContains := func(name string, snippets ...string) {
+		for _, snippet := range snippets {
+			if !strings.Contains(string(files[name]), snippet) {
+				t.Fatalf("expected %s to contain %q but got:\n%s", name, snippet, files[name])
+			}
+		}
+	}
+	assertContains("package.json", `"type": "module"`)
+	assertContains("index.js",
+		`import localeFr from "./locales/fr.js";`,
+		`"fr": Object.assign({}, localeEn, localeFr),`,
+		`export { sourceLocale, locales, setLocale, getLocale, __ };`)
+	assertContains("index.d.ts",
+		`export type Locale = "en" | "fr";`,
+		`"inbox.count": [count: number];`,
+		`export declare function __<K extends keyof Messages>(key: K, ...args: Messages[K]): string;`)
+	assertContains("ui.d.ts", `"title": [type: string];`)
+	assertContains("ui.js", `return translate("ui." + key, ...args);`)
+	assertContains("locales/en.js",
+		`"greeting": (name) => "Hello, " + name + "!",`,
+		`"inbox.count": (count) => "You have " + (count === 0 ? "no messages" : count === 1 ? "one message" : `+
+			`("" + count + " messages")) + ".",`,
+		`"ui.title": (type) => "Welcome " + (type === "admin" ? "administrator" : ("" + type)),`)
+	if strings.Contains(string(files["locales/fr.js"]), `"untranslated"`) {
+		t.Fatal("expected untranslated messages to be left out of locales/fr.js")
+	}
+}
+
+func TestGenerateJSSelectors(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/selectors")
+	if err != nil {
+		t.Fatalf("error creating bundle: %s", err)
+	}
+	files, err := release.GenerateJS(bundle, release.JSOptions{Module: project.ESModule, TranslateFn: "__"})
+	if err != nil {
+		t.Fatalf("error generating JavaScript code: %s", err)
+	}
+	en := string(files["locales/en.js"])
+	if !strings.Contains(en, "=== 10 ?") || !strings.Contains(en, "=== -1 ?") {
+		t.Fatalf("expected number selectors without leading zeros but got:\n%s", en)
+	}
+}
+
+func TestGenerateMinifiedCommonJS(t *testing.T) {
+	bundle, err := loadBundle(t, "testdata/lang")
+	if err != nil {
+		t.Fatalf("error creating bundle: %s", err)
+	}
+	files, err := release.GenerateJS(bundle, release.JSOptions{Module: project.CommonJS, Minify: true, TranslateFn: "t"})
+	if err != nil {
+		t.Fatalf("error generating JavaScript code: %s", err)
+	}
+
+	index := string(files["index.js"])
+	if lines := strings.Split(strings.TrimSpace(index), "\n"); len(lines) != 2 {
+		t.Fatalf("expected index.js to be minified on a single line after the header but got:\n%s", index)
+	}
+	if !strings.Contains(index, `const localeEn=require("./locales/en.js");`) ||
+		!strings.Contains(index, `module.exports={sourceLocale,locales,setLocale,getLocale,t};`) {
+		t.Fatalf("expected index.js to be a CommonJS module but got:\n%s", index)
+	}
+	if !strings.Contains(string(files["locales/en.js"]), `"price":(amount)=>""+amount+" dollars",`) {
+		t.Fatalf("expected spaces in strings to be kept but got:\n%s", files["locales/en.js"])
+	}
+
+	if _, err := release.GenerateJS(bundle, release.JSOptions{TranslateFn: "setLocale"}); err == nil {
+		t.Fatal("expected setLocale not to be accepted as the name of the translate function")
+	}
+}
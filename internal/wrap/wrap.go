@@ -75,8 +75,11 @@ func wrapWord(text string, minWidth int, maxWidth int) (measuredString, measured
 	return measuredString{}, concat(prefix, suffix), text
 }
 
-// Break a string into lines of at most [width] runes.
+// Break a string into lines of at most [width] runes. If [width] is not positive, the text is not wrapped.
 func Wrap(text string, width int) (result []string) {
+	if width <= 0 {
+		return []string{text}
+	}
 	var line measuredString
 	for len(text) > 0 {
 		var cont, next measuredString
@@ -45,6 +45,12 @@ func TestWrapWithoutSpace(t *testing.T) {
 	assertSameLines(t, wrapped, expected)
 }
 
+func TestWrapUnlimited(t *testing.T) {
+	wrapped := wrap.Wrap(text, 0)
+	expected := []string{"Or was it because of the involvement of something from beyond science?"}
+	assertSameLines(t, wrapped, expected)
+}
+
 func TestIndent(t *testing.T) {
 	lines := []string{"Or was it because of the", "involvement of something", "from beyond science?"}
 	indented := wrap.Indent("|  ", lines)
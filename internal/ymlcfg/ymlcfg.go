@@ -1,125 +1,258 @@
 package ymlcfg
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// A position in a configuration file, starting from 1.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+func (pos Pos) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
+// An error about a value of a configuration file.
+type Error struct {
+	// The path of the file, which may be empty, or where the value comes from if it was not read from a file.
+	File string
+	Pos  Pos
+	// The full key of the value, like "sources.b[1]".
+	Path string
+	Msg  string
+}
+
+func (err *Error) Error() string {
+	var msg strings.Builder
+	// values that were not read from a file have no position
+	switch {
+	case err.File != "" && err.Pos.Line > 0:
+		msg.WriteString(err.File + ":" + err.Pos.String() + ": ")
+	case err.File != "":
+		msg.WriteString(err.File + ": ")
+	default:
+		msg.WriteString(err.Pos.String() + ": ")
+	}
+	if err.Path != "" {
+		msg.WriteString(err.Path + ": ")
+	}
+	msg.WriteString(err.Msg)
+	return msg.String()
+}
+
+// The kind of a configuration value.
+type Kind uint8
+
+const (
+	// A missing value.
+	Nil Kind = iota
+	Scalar
+	Sequence
+	Mapping
+)
+
 type ConfigValue interface {
 	Get(key string) ConfigValue
-	BindStr() (string, bool)
-	BindStrSeq() ([]string, bool)
-	BindMap(iter func(key string, value ConfigValue)) bool
+	BindStr() (string, error)
+	BindStrSeq() ([]string, error)
+	// Call [iter] for each entry of a mapping, in the order of the document, and return the errors it returned.
+	BindMap(iter func(key string, value ConfigValue) error) error
+	Kind() Kind
+	// The position of the value in the file. Missing values are at the position of the mapping they would be in.
+	Pos() Pos
+	// The full key of the value, like "sources.b[1]".
+	Path() string
+	// Create an error about the value, prefixed with its position and path.
+	Errorf(format string, args ...any) error
+}
+
+// What every kind of value knows about its location.
+type configBase struct {
+	file string
+	path string
+	pos  Pos
 }
 
-type configNil struct{}
+func (cfg configBase) Pos() Pos {
+	return cfg.pos
+}
+
+func (cfg configBase) Path() string {
+	return cfg.path
+}
+
+func (cfg configBase) Errorf(format string, args ...any) error {
+	return &Error{File: cfg.file, Pos: cfg.pos, Path: cfg.path, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Return the base of a value nested in [cfg].
+func (cfg configBase) child(suffix string, node *yaml.Node) configBase {
+	child := configBase{file: cfg.file, path: cfg.path + suffix, pos: cfg.pos}
+	if cfg.path == "" {
+		child.path = strings.TrimPrefix(child.path, ".")
+	}
+	if node != nil {
+		child.pos = Pos{node.Line, node.Column}
+	}
+	return child
+}
+
+type configNil struct {
+	configBase
+}
 
 func (cfg *configNil) Get(key string) ConfigValue {
-	return cfg
+	return &configNil{cfg.child("."+key, nil)}
+}
+
+func (cfg *configNil) BindStr() (string, error) {
+	return "", nil
 }
 
-func (cfg *configNil) BindStr() (string, bool) {
-	return "", true
+func (cfg *configNil) BindStrSeq() ([]string, error) {
+	return nil, nil
 }
 
-func (cfg *configNil) BindStrSeq() ([]string, bool) {
-	return nil, true
+func (cfg *configNil) BindMap(iter func(key string, value ConfigValue) error) error {
+	return cfg.Errorf("expected mapping")
 }
 
-func (cfg *configNil) BindMap(iter func(key string, value ConfigValue)) bool {
-	return false
+func (cfg *configNil) Kind() Kind {
+	return Nil
 }
 
 type configScalar struct {
+	configBase
 	node  *yaml.Node
 	bound bool
 }
 
 func (cfg *configScalar) Get(key string) ConfigValue {
-	return &configNil{}
+	return &configNil{cfg.child("."+key, nil)}
 }
 
-func (cfg *configScalar) BindStr() (string, bool) {
-	return cfg.node.Value, true
+func (cfg *configScalar) BindStr() (string, error) {
+	cfg.bound = true
+	return cfg.node.Value, nil
 }
 
-func (cfg *configScalar) BindStrSeq() ([]string, bool) {
-	return []string{cfg.node.Value}, true
+func (cfg *configScalar) BindStrSeq() ([]string, error) {
+	cfg.bound = true
+	return []string{cfg.node.Value}, nil
 }
 
-func (cfg *configScalar) BindMap(iter func(key string, value ConfigValue)) bool {
-	return false
+func (cfg *configScalar) BindMap(iter func(key string, value ConfigValue) error) error {
+	return cfg.Errorf("expected mapping")
+}
+
+func (cfg *configScalar) Kind() Kind {
+	return Scalar
 }
 
 type configSequence struct {
+	configBase
 	node   *yaml.Node
 	bound  bool
 	values []ConfigValue
 }
 
 func (cfg *configSequence) Get(key string) ConfigValue {
-	return &configNil{}
+	return &configNil{cfg.child("."+key, nil)}
 }
 
-func (cfg *configSequence) BindStr() (string, bool) {
-	return "", false
+func (cfg *configSequence) BindStr() (string, error) {
+	return "", cfg.Errorf("expected string")
 }
 
-func (cfg *configSequence) BindStrSeq() ([]string, bool) {
+func (cfg *configSequence) BindStrSeq() ([]string, error) {
+	cfg.bound = true
 	values := make([]string, len(cfg.values))
-	for i, val := range(cfg.values) {
-		ok := true
-		values[i], ok = val.BindStr()
-		if !ok {
-			return nil, false
+	for i, val := range cfg.values {
+		var err error
+		values[i], err = val.BindStr()
+		if err != nil {
+			return nil, err
 		}
 	}
-	return values, true
+	return values, nil
+}
+
+func (cfg *configSequence) BindMap(iter func(key string, value ConfigValue) error) error {
+	return cfg.Errorf("expected mapping")
 }
 
-func (cfg *configSequence) BindMap(iter func(key string, value ConfigValue)) bool {
-	return false
+func (cfg *configSequence) Kind() Kind {
+	return Sequence
 }
 
 type configMappingEntry struct {
+	// the file the entry was read from, which may be different from the one of the mapping if it was merged
+	file    string
 	keyNode *yaml.Node
 	bound   bool
 	value   ConfigValue
 }
 
 type configMapping struct {
+	configBase
 	node    *yaml.Node
 	bound   bool
-	entries map[string]configMappingEntry
+	entries map[string]*configMappingEntry
+	// the keys in the order of the document
+	keys []string
+	// the keys that were looked up, including missing ones
+	requested []string
 }
 
 func (cfg *configMapping) Get(key string) ConfigValue {
 	cfg.bound = true
+	if !slices.Contains(cfg.requested, key) {
+		cfg.requested = append(cfg.requested, key)
+	}
 	entry := cfg.entries[key]
-	if entry.value == nil {
-		return &configNil{}
+	if entry == nil {
+		return &configNil{cfg.child("."+key, nil)}
 	} else {
+		entry.bound = true
 		return entry.value
 	}
 }
 
-func (cfg *configMapping) BindStr() (string, bool) {
-	return "", false
+func (cfg *configMapping) BindStr() (string, error) {
+	return "", cfg.Errorf("expected string")
 }
 
-func (cfg *configMapping) BindStrSeq() ([]string, bool) {
-	return nil, false
+func (cfg *configMapping) BindStrSeq() ([]string, error) {
+	return nil, cfg.Errorf("expected string or list of strings")
 }
 
-func (cfg *configMapping) BindMap(iter func(key string, value ConfigValue)) bool {
-	for key, entry := range cfg.entries {
-		iter(key, entry.value)
+func (cfg *configMapping) BindMap(iter func(key string, value ConfigValue) error) error {
+	cfg.bound = true
+	var errs []error
+	for _, key := range cfg.keys {
+		entry := cfg.entries[key]
+		entry.bound = true
+		if err := iter(key, entry.value); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return true
+	return errors.Join(errs...)
+}
+
+func (cfg *configMapping) Kind() Kind {
+	return Mapping
 }
 
 type configDocument struct {
+	configBase
 	node    *yaml.Node
 	bound   bool
 	content ConfigValue
@@ -130,43 +263,56 @@ func (cfg *configDocument) Get(key string) ConfigValue {
 	return cfg.content.Get(key)
 }
 
-func (cfg *configDocument) BindStr() (string, bool) {
+func (cfg *configDocument) BindStr() (string, error) {
 	cfg.bound = true
 	return cfg.content.BindStr()
 }
 
-func (cfg *configDocument) BindStrSeq() ([]string, bool) {
+func (cfg *configDocument) BindStrSeq() ([]string, error) {
 	cfg.bound = true
 	return cfg.content.BindStrSeq()
 }
 
-func (cfg *configDocument) BindMap(iter func(key string, value ConfigValue)) bool {
+func (cfg *configDocument) BindMap(iter func(key string, value ConfigValue) error) error {
 	cfg.bound = true
 	return cfg.content.BindMap(iter)
 }
 
+func (cfg *configDocument) Kind() Kind {
+	return cfg.content.Kind()
+}
+
 func FromNode(data *yaml.Node) (ConfigValue, error) {
+	return fromNode(data, configBase{pos: Pos{1, 1}})
+}
+
+func fromNode(data *yaml.Node, base configBase) (ConfigValue, error) {
 	var (
 		cfg ConfigValue
 		err error
 	)
 	switch data.Kind {
 	case yaml.DocumentNode:
-		document := configDocument{node: data}
+		document := configDocument{configBase: base, node: data}
 		if len(data.Content) > 0 {
-			document.content, err = FromNode(data.Content[0])
+			document.content, err = fromNode(data.Content[0], base)
+		} else {
+			document.content = &configNil{base}
 		}
 		cfg = &document
 
 	case yaml.ScalarNode:
-		scalar := configScalar{node: data}
+		scalar := configScalar{configBase: base, node: data}
 		cfg = &scalar
 
+	case yaml.AliasNode:
+		cfg, err = fromNode(data.Alias, base)
+
 	case yaml.SequenceNode:
-		sequence := configSequence{node: data}
+		sequence := configSequence{configBase: base, node: data}
 		var value ConfigValue
-		for _, node := range data.Content {
-			value, err = FromNode(node)
+		for i, node := range data.Content {
+			value, err = fromNode(node, base.child(fmt.Sprintf("[%d]", i), node))
 			if err != nil {
 				break
 			}
@@ -177,10 +323,11 @@ func FromNode(data *yaml.Node) (ConfigValue, error) {
 
 	case yaml.MappingNode:
 		mapping := configMapping{
-			node:    data,
-			entries: make(map[string]configMappingEntry, len(data.Content)/2),
+			configBase: base,
+			node:       data,
+			entries:    make(map[string]*configMappingEntry, len(data.Content)/2),
 		}
-		var entry configMappingEntry
+		entry := &configMappingEntry{file: base.file}
 		for _, node := range data.Content {
 			if entry.keyNode == nil {
 				entry.keyNode = node
@@ -189,46 +336,77 @@ func FromNode(data *yaml.Node) (ConfigValue, error) {
 				if entry.keyNode.ShortTag() == "!!str" {
 					key = entry.keyNode.Value
 				} else {
-					err = fmt.Errorf("unable to map node %v into a config key", *entry.keyNode)
+					keyBase := base.child("", entry.keyNode)
+					err = keyBase.Errorf("expected a string key but got %s", describeNode(entry.keyNode))
 				}
 				if err != nil {
 					break
 				}
 
-				entry.value, err = FromNode(node)
+				entry.value, err = fromNode(node, base.child("."+key, node))
 				if err != nil {
 					break
 				}
 
+				if _, exists := mapping.entries[key]; !exists {
+					mapping.keys = append(mapping.keys, key)
+				}
 				mapping.entries[key] = entry
-				entry = configMappingEntry{}
+				entry = &configMappingEntry{file: base.file}
 			}
 		}
 		cfg = &mapping
 
 	default:
-		err = fmt.Errorf("unable to map node %v into a config value", *data)
+		err = base.child("", data).Errorf("unexpected %s", describeNode(data))
 	}
 	return cfg, err
 }
 
+// Describe a node in error messages.
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return "a sequence"
+	case yaml.MappingNode:
+		return "a mapping"
+	default:
+		return fmt.Sprintf("%q (%s)", node.Value, strings.TrimPrefix(node.ShortTag(), "!!"))
+	}
+}
+
 func FromText(data []byte) (ConfigValue, error) {
+	return fromText("", data)
+}
+
+// Read a configuration file. Errors are prefixed with [path].
+func FromFile(path string) (ConfigValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &configNil{}, err
+	}
+	return fromText(path, data)
+}
+
+func fromText(path string, data []byte) (ConfigValue, error) {
 	var rootNode yaml.Node
 	err := yaml.Unmarshal(data, &rootNode)
 	if err != nil {
+		if path != "" {
+			err = fmt.Errorf("%s: %w", path, err)
+		}
 		return &configNil{}, err
 	}
 
-	return FromNode(&rootNode)
+	return fromNode(&rootNode, configBase{file: path, pos: Pos{1, 1}})
 }
 
-func BindMap[V any](cfg ConfigValue, binding func(cfg ConfigValue)(V, bool)) (map[string]V, bool) {
+func BindMap[V any](cfg ConfigValue, binding func(cfg ConfigValue) (V, error)) (map[string]V, error) {
 	mapped := make(map[string]V)
-	bound := true
-	supportsBindMap := cfg.BindMap(func(key string, value ConfigValue) {
-		var ok bool
-		mapped[key], ok = binding(value)
-		bound = bound && ok
+	err := cfg.BindMap(func(key string, value ConfigValue) error {
+		var err error
+		mapped[key], err = binding(value)
+		return err
 	})
-	return mapped, supportsBindMap && bound
-}
\ No newline at end of file
+	return mapped, err
+}
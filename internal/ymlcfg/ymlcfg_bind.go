@@ -0,0 +1,297 @@
+package ymlcfg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Read an integer between [minimum] and [maximum] included, or [def] if the value is missing. Use math.MinInt and
+// math.MaxInt for ranges without a bound.
+func BindInt(cfg ConfigValue, def int, minimum int, maximum int) (int, error) {
+	if cfg.Kind() == Nil {
+		return def, nil
+	}
+	text, err := cfg.BindStr()
+	if err != nil {
+		return def, cfg.Errorf("expected %s", describeRange(minimum, maximum))
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil || value < minimum || value > maximum {
+		return def, cfg.Errorf("expected %s, but got %q", describeRange(minimum, maximum), text)
+	}
+	return value, nil
+}
+
+func describeRange(minimum int, maximum int) string {
+	switch {
+	case minimum == math.MinInt && maximum == math.MaxInt:
+		return "an integer"
+	case maximum == math.MaxInt:
+		return fmt.Sprintf("an integer greater than or equal to %d", minimum)
+	case minimum == math.MinInt:
+		return fmt.Sprintf("an integer less than or equal to %d", maximum)
+	default:
+		return fmt.Sprintf("an integer between %d and %d", minimum, maximum)
+	}
+}
+
+// Read a duration written like 1m30s between [minimum] and [maximum] included, or [def] if the value is missing. Use
+// math.MinInt64 and math.MaxInt64 for ranges without a bound.
+func BindDuration(cfg ConfigValue, def time.Duration, minimum time.Duration, maximum time.Duration) (time.Duration,
+	error) {
+	if cfg.Kind() == Nil {
+		return def, nil
+	}
+	text, err := cfg.BindStr()
+	if err != nil {
+		return def, cfg.Errorf("expected %s", describeDurationRange(minimum, maximum))
+	}
+	value, err := time.ParseDuration(text)
+	if err != nil || value < minimum || value > maximum {
+		return def, cfg.Errorf("expected %s, but got %q", describeDurationRange(minimum, maximum), text)
+	}
+	return value, nil
+}
+
+func describeDurationRange(minimum time.Duration, maximum time.Duration) string {
+	switch {
+	case minimum == math.MinInt64 && maximum == math.MaxInt64:
+		return "a duration like 1m30s"
+	case maximum == math.MaxInt64:
+		return fmt.Sprintf("a duration like 1m30s of at least %s", minimum)
+	case minimum == math.MinInt64:
+		return fmt.Sprintf("a duration like 1m30s of at most %s", maximum)
+	default:
+		return fmt.Sprintf("a duration like 1m30s between %s and %s", minimum, maximum)
+	}
+}
+
+// Read a boolean, or [def] if the value is missing.
+func BindBool(cfg ConfigValue, def bool) (bool, error) {
+	if cfg.Kind() == Nil {
+		return def, nil
+	}
+	text, err := cfg.BindStr()
+	if err == nil {
+		switch strings.ToLower(text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return def, cfg.Errorf("expected true or false")
+}
+
+// A value accepted by BindEnum, with the names it can be written with.
+type EnumValue[T any] struct {
+	Value T
+	Names []string
+}
+
+// Read one of [values] from its name, or [def] if the value is missing.
+func BindEnum[T any](cfg ConfigValue, def T, values ...EnumValue[T]) (T, error) {
+	if cfg.Kind() == Nil {
+		return def, nil
+	}
+	text, err := cfg.BindStr()
+	if err == nil {
+		for _, value := range values {
+			for _, name := range value.Names {
+				if name == text {
+					return value.Value, nil
+				}
+			}
+		}
+	}
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = value.Names[0]
+	}
+	return def, cfg.Errorf("expected %s", describeChoice(names))
+}
+
+// List the names of enum values, like "one of a, b or c".
+func describeChoice(names []string) string {
+	switch len(names) {
+	case 0:
+		return "nothing"
+	case 1:
+		return names[0]
+	default:
+		return "one of " + strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+	}
+}
+
+// A type that reads itself from a configuration value, when it is a field of a struct passed to Bind.
+type Bindable interface {
+	BindConfig(cfg ConfigValue) error
+}
+
+// Fill the fields of the struct pointed to by [target] from a mapping. Every field is read, and the errors are
+// returned together.
+//
+// Fields are read according to their tags:
+//   - ymlcfg: the key of the field, fields without it are ignored
+//   - default: the value of the field if the key is missing
+//   - min and max: the range of integer and duration fields
+//   - enum: the names of the values of an integer field, separated by commas, with aliases separated by |. The field
+//     is set to the index of the name.
+//   - doc: a description of the field, used in schemas
+//
+// Fields can be strings, lists of strings, booleans, integers, time.Duration, structs read in the same way, or types
+// implementing Bindable.
+func Bind(cfg ConfigValue, target any) error {
+	v := reflect.ValueOf(target).Elem()
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		if _, ok := v.Type().Field(i).Tag.Lookup("ymlcfg"); ok {
+			errs = append(errs, bindField(cfg, v, i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Fill one field of the struct pointed to by [target], given its name in Go. See Bind for the tags used.
+func BindField(cfg ConfigValue, target any, name string) error {
+	v := reflect.ValueOf(target).Elem()
+	field, ok := v.Type().FieldByName(name)
+	if !ok {
+		panic(fmt.Sprintf("ymlcfg: %s has no field %s", v.Type(), name))
+	}
+	return bindField(cfg, v, field.Index[0])
+}
+
+func bindField(cfg ConfigValue, v reflect.Value, i int) error {
+	field := v.Type().Field(i)
+	key, ok := field.Tag.Lookup("ymlcfg")
+	if !ok {
+		panic(fmt.Sprintf("ymlcfg: field %s of %s has no key", field.Name, v.Type()))
+	}
+	value := cfg.Get(key)
+	target := v.Field(i)
+	def := field.Tag.Get("default")
+
+	if bindable, ok := target.Addr().Interface().(Bindable); ok {
+		return bindable.BindConfig(value)
+	}
+
+	switch {
+	case field.Tag.Get("enum") != "":
+		var values []EnumValue[int]
+		for i, names := range strings.Split(field.Tag.Get("enum"), ",") {
+			values = append(values, EnumValue[int]{Value: i, Names: strings.Split(names, "|")})
+		}
+		index, err := BindEnum(value, -1, values...)
+		if index < 0 {
+			index = max(0, indexOfName(values, def))
+		}
+		setInt(target, index)
+		return err
+
+	case target.Kind() == reflect.String:
+		text, err := value.BindStr()
+		if text == "" {
+			text = def
+		}
+		target.SetString(text)
+		return err
+
+	case target.Kind() == reflect.Bool:
+		b, err := BindBool(value, def == "true")
+		target.SetBool(b)
+		return err
+
+	case target.Type() == durationType:
+		minimum, maximum := time.Duration(math.MinInt64), time.Duration(math.MaxInt64)
+		if tag, ok := field.Tag.Lookup("min"); ok {
+			minimum = mustParseDuration(tag)
+		}
+		if tag, ok := field.Tag.Lookup("max"); ok {
+			maximum = mustParseDuration(tag)
+		}
+		var defValue time.Duration
+		if def != "" {
+			defValue = mustParseDuration(def)
+		}
+		d, err := BindDuration(value, defValue, minimum, maximum)
+		target.SetInt(int64(d))
+		return err
+
+	case target.CanInt() || target.CanUint():
+		minimum, maximum := math.MinInt, math.MaxInt
+		if tag, ok := field.Tag.Lookup("min"); ok {
+			minimum = mustAtoi(tag)
+		}
+		if tag, ok := field.Tag.Lookup("max"); ok {
+			maximum = mustAtoi(tag)
+		}
+		n, err := BindInt(value, 0, minimum, maximum)
+		if value.Kind() == Nil || err != nil {
+			if def != "" {
+				n = mustAtoi(def)
+			}
+		}
+		setInt(target, n)
+		return err
+
+	case target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.String:
+		list, err := value.BindStrSeq()
+		if value.Kind() == Nil && def != "" {
+			list = strings.Split(def, ",")
+		}
+		target.Set(reflect.ValueOf(list).Convert(target.Type()))
+		return err
+
+	case target.Kind() == reflect.Struct:
+		if value.Kind() != Nil && value.Kind() != Mapping {
+			return value.Errorf("expected mapping")
+		}
+		return Bind(value, target.Addr().Interface())
+
+	default:
+		panic(fmt.Sprintf("ymlcfg: cannot bind field %s of type %s", field.Name, target.Type()))
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func indexOfName(values []EnumValue[int], name string) int {
+	for _, value := range values {
+		for _, n := range value.Names {
+			if n == name {
+				return value.Value
+			}
+		}
+	}
+	return -1
+}
+
+func setInt(target reflect.Value, n int) {
+	if target.CanInt() {
+		target.SetInt(int64(n))
+	} else {
+		target.SetUint(uint64(n))
+	}
+}
+
+func mustAtoi(tag string) int {
+	n, err := strconv.Atoi(tag)
+	if err != nil {
+		panic(fmt.Sprintf("ymlcfg: invalid integer %q in a tag", tag))
+	}
+	return n
+}
+
+func mustParseDuration(tag string) time.Duration {
+	d, err := time.ParseDuration(tag)
+	if err != nil {
+		panic(fmt.Sprintf("ymlcfg: invalid duration %q in a tag", tag))
+	}
+	return d
+}
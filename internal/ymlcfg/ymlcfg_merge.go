@@ -0,0 +1,50 @@
+package ymlcfg
+
+// Combine two configurations, the values of [override] replacing those of [base]. Mappings are merged recursively,
+// and every value keeps the file and position it was read from, so errors point at the file that defined it.
+func Merge(base ConfigValue, override ConfigValue) ConfigValue {
+	if doc, ok := base.(*configDocument); ok {
+		base = doc.content
+	}
+	if doc, ok := override.(*configDocument); ok {
+		override = doc.content
+	}
+
+	if override.Kind() == Nil {
+		return base
+	}
+	baseMapping, ok := base.(*configMapping)
+	if !ok {
+		return override
+	}
+	overrideMapping, ok := override.(*configMapping)
+	if !ok {
+		return override
+	}
+
+	merged := &configMapping{
+		configBase: overrideMapping.configBase,
+		node:       overrideMapping.node,
+		entries:    make(map[string]*configMappingEntry, len(baseMapping.keys)+len(overrideMapping.keys)),
+		keys:       append([]string(nil), baseMapping.keys...),
+	}
+	for key, entry := range baseMapping.entries {
+		merged.entries[key] = entry
+	}
+	for _, key := range overrideMapping.keys {
+		entry := overrideMapping.entries[key]
+		if baseEntry, exists := merged.entries[key]; exists {
+			// the entry is copied so that the original mapping is left unchanged
+			entry = &configMappingEntry{
+				file:    entry.file,
+				keyNode: entry.keyNode,
+				bound:   entry.bound,
+				value:   Merge(baseEntry.value, entry.value),
+			}
+		} else {
+			merged.keys = append(merged.keys, key)
+		}
+		merged.entries[key] = entry
+	}
+	return merged
+}
@@ -0,0 +1,129 @@
+package ymlcfg
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Replace the variables in the scalars of [cfg], written ${NAME} or ${NAME:-default}, with their value given by
+// [lookup]. The default is used when the variable is not set or empty, and $${ is left as ${.
+func Interpolate(cfg ConfigValue, lookup func(name string) (string, bool)) (ConfigValue, error) {
+	switch cfg := cfg.(type) {
+	case *configDocument:
+		content, err := Interpolate(cfg.content, lookup)
+		return &configDocument{configBase: cfg.configBase, node: cfg.node, content: content}, err
+
+	case *configScalar:
+		if !strings.Contains(cfg.node.Value, "${") {
+			return cfg, nil
+		}
+		value, err := expand(cfg.node.Value, lookup)
+		if err != nil {
+			return cfg, cfg.Errorf("%s", err)
+		}
+		node := *cfg.node
+		node.Value = value
+		return &configScalar{configBase: cfg.configBase, node: &node}, nil
+
+	case *configSequence:
+		sequence := &configSequence{configBase: cfg.configBase, node: cfg.node}
+		sequence.values = make([]ConfigValue, len(cfg.values))
+		var errs []error
+		for i, value := range cfg.values {
+			var err error
+			sequence.values[i], err = Interpolate(value, lookup)
+			errs = append(errs, err)
+		}
+		return sequence, errors.Join(errs...)
+
+	case *configMapping:
+		mapping := &configMapping{
+			configBase: cfg.configBase,
+			node:       cfg.node,
+			entries:    make(map[string]*configMappingEntry, len(cfg.entries)),
+			keys:       cfg.keys,
+		}
+		var errs []error
+		for _, key := range cfg.keys {
+			entry := *cfg.entries[key]
+			var err error
+			entry.value, err = Interpolate(entry.value, lookup)
+			errs = append(errs, err)
+			mapping.entries[key] = &entry
+		}
+		return mapping, errors.Join(errs...)
+
+	default:
+		return cfg, nil
+	}
+}
+
+// Replace the variables in a string.
+func expand(text string, lookup func(name string) (string, bool)) (string, error) {
+	var out strings.Builder
+	for {
+		start := strings.Index(text, "${")
+		if start < 0 {
+			break
+		}
+		if start > 0 && text[start-1] == '$' {
+			out.WriteString(text[:start-1])
+			out.WriteString("${")
+			text = text[start+2:]
+			continue
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			return "", errors.New("unterminated variable, expected a closing brace")
+		}
+		name, def, hasDefault := strings.Cut(text[start+2:start+end], ":-")
+		if name == "" {
+			return "", errors.New("expected a variable name after ${")
+		}
+		value, ok := lookup(name)
+		if (!ok || value == "") && hasDefault {
+			value = def
+		}
+		out.WriteString(text[:start])
+		out.WriteString(value)
+		text = text[start+end+1:]
+	}
+	out.WriteString(text)
+	return out.String(), nil
+}
+
+// Set the value of a key of [cfg], [key] being a full key like "format.printWidth". The value is read as YAML, so it
+// can be a list or a mapping, and [origin] is used instead of a file name and position in errors.
+func Override(cfg ConfigValue, key string, value string, origin string) (ConfigValue, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &document); err != nil || len(document.Content) == 0 {
+		document.Content = []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}}
+	}
+	node := document.Content[0]
+	clearPositions(node)
+
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == "" {
+			return cfg, errors.New(origin + ": invalid key " + key)
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parts[i]}
+		node = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, node}}
+	}
+
+	override, err := fromNode(node, configBase{file: origin})
+	if err != nil {
+		return cfg, err
+	}
+	return Merge(cfg, override), nil
+}
+
+// Remove the positions of the nodes, since they are meaningless outside of a file.
+func clearPositions(node *yaml.Node) {
+	node.Line, node.Column = 0, 0
+	for _, child := range node.Content {
+		clearPositions(child)
+	}
+}
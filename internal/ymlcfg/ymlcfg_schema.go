@@ -0,0 +1,126 @@
+package ymlcfg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A type that describes its own JSON schema, when it is a field of a struct passed to Schema.
+type SchemaProvider interface {
+	ConfigSchema() map[string]any
+}
+
+// Return the JSON schema of the values read by Bind into a struct of the same type as [v], using the same tags.
+func Schema(v any) map[string]any {
+	return structSchema(reflect.TypeOf(v))
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if key, ok := field.Tag.Lookup("ymlcfg"); ok {
+			properties[key] = fieldSchema(field)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func fieldSchema(field reflect.StructField) map[string]any {
+	var schema map[string]any
+	def, hasDefault := field.Tag.Lookup("default")
+
+	if provider, ok := reflect.New(field.Type).Interface().(SchemaProvider); ok {
+		schema = provider.ConfigSchema()
+	} else if enum := field.Tag.Get("enum"); enum != "" {
+		var names []any
+		for i, aliases := range strings.Split(enum, ",") {
+			if i == 0 && !hasDefault {
+				def, hasDefault = strings.Split(aliases, "|")[0], true
+			}
+			for _, name := range strings.Split(aliases, "|") {
+				names = append(names, name)
+			}
+		}
+		schema = map[string]any{"type": "string", "enum": names}
+	} else {
+		switch k := field.Type.Kind(); {
+		case k == reflect.String:
+			schema = map[string]any{"type": "string"}
+		case k == reflect.Bool:
+			schema = map[string]any{"type": "boolean"}
+		case field.Type == durationType:
+			// the format of time.ParseDuration, like 1m30s
+			schema = map[string]any{"type": "string", "pattern": `^[-+]?(0|([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`}
+		case field.Type.ConvertibleTo(reflect.TypeOf(0)):
+			schema = map[string]any{"type": "integer"}
+			if tag, ok := field.Tag.Lookup("min"); ok {
+				schema["minimum"] = mustAtoi(tag)
+			}
+			if tag, ok := field.Tag.Lookup("max"); ok {
+				schema["maximum"] = mustAtoi(tag)
+			}
+		case k == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+			// a single string is accepted as a list of one string
+			schema = map[string]any{
+				"anyOf": []any{
+					map[string]any{"type": "string"},
+					map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+			}
+		case k == reflect.Struct:
+			schema = structSchema(field.Type)
+		default:
+			panic(fmt.Sprintf("ymlcfg: no schema for field %s of type %s", field.Name, field.Type))
+		}
+	}
+
+	if doc := field.Tag.Get("doc"); doc != "" {
+		schema["description"] = doc
+	}
+	if hasDefault {
+		schema["default"] = defaultValue(schema, def)
+	}
+	return schema
+}
+
+// Convert a default value from its tag to the type of the schema.
+func defaultValue(schema map[string]any, tag string) any {
+	switch schema["type"] {
+	case "boolean":
+		return tag == "true"
+	case "integer":
+		return mustAtoi(tag)
+	default:
+		return tag
+	}
+}
+
+// Return the full keys of the values read by Bind into a struct of the same type as [v], like "format.printWidth".
+// Nested structs are listed through their fields only.
+func Keys(v any) []string {
+	return structKeys(reflect.TypeOf(v), "")
+}
+
+func structKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := field.Tag.Lookup("ymlcfg")
+		if !ok {
+			continue
+		}
+		_, bindable := reflect.New(field.Type).Interface().(Bindable)
+		if field.Type.Kind() == reflect.Struct && !bindable {
+			keys = append(keys, structKeys(field.Type, prefix+key+".")...)
+		} else {
+			keys = append(keys, prefix+key)
+		}
+	}
+	return keys
+}
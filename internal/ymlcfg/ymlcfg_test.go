@@ -1,7 +1,11 @@
 package ymlcfg_test
 
 import (
+	"encoding/json"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/louisdevie/elizalina2/internal/ymlcfg"
 )
@@ -22,15 +26,15 @@ func TestBindStr(t *testing.T) {
 		t.Fatalf("error parsing yaml data: %s", err)
 	}
 
-	if a, ok := cfg.Get("a").BindStr(); !ok || a != "text" {
+	if a, err := cfg.Get("a").BindStr(); err != nil || a != "text" {
 		t.Fatalf("Expected [.a] to be text but got %v", a)
 	}
 
-	if b, ok := cfg.Get("b").BindStr(); !ok || b != "45" {
+	if b, err := cfg.Get("b").BindStr(); err != nil || b != "45" {
 		t.Fatalf("Expected [.b] to be 45 but got %v", b)
 	}
 
-	if _, ok := cfg.Get("c").BindStr(); ok {
+	if _, err := cfg.Get("c").BindStr(); err == nil {
 		t.Fatal("Expected [.c] not to bind but it did")
 	}
 }
@@ -41,7 +45,7 @@ func TestBindStrSeq(t *testing.T) {
 		t.Fatalf("error parsing yaml data: %s", err)
 	}
 
-	if a, ok := cfg.Get("a").BindStrSeq(); ok {
+	if a, err := cfg.Get("a").BindStrSeq(); err == nil {
 		if len(a) != 1 || a[0] != "text" {
 			t.Fatalf("Expected [.a] to be [text] but got %v", a)
 		}
@@ -49,7 +53,7 @@ func TestBindStrSeq(t *testing.T) {
 		t.Fatal("Expected [.a] to bind but it did not")
 	}
 
-	if b, ok := cfg.Get("b").BindStrSeq(); ok {
+	if b, err := cfg.Get("b").BindStrSeq(); err == nil {
 		if len(b) != 1 || b[0] != "45" {
 			t.Fatalf("Expected [.b] to be [45] but got %v", b)
 		}
@@ -57,7 +61,7 @@ func TestBindStrSeq(t *testing.T) {
 		t.Fatal("Expected [.b] to bind but it did not")
 	}
 
-	if c, ok := cfg.Get("c").BindStrSeq(); ok {
+	if c, err := cfg.Get("c").BindStrSeq(); err == nil {
 		if len(c) != 2 || c[0] != "first" || c[1] != "second" {
 			t.Fatalf("Expected [.c] to be [first second] but got %v", c)
 		}
@@ -72,7 +76,7 @@ func TestBindMap(t *testing.T) {
 		t.Fatalf("error parsing yaml data: %s", err)
 	}
 
-	if d, ok := ymlcfg.BindMap(cfg.Get("d"), ymlcfg.ConfigValue.BindStr); ok {
+	if d, err := ymlcfg.BindMap(cfg.Get("d"), ymlcfg.ConfigValue.BindStr); err == nil {
 		if len(d) != 3 {
 			t.Fatalf("Expected [.d] to contain 3 elements but got %v", len(d))
 		}
@@ -88,4 +92,255 @@ func TestBindMap(t *testing.T) {
 	} else {
 		t.Fatal("Expected [.d] to bind but it did not")
 	}
-}
\ No newline at end of file
+}
+
+func TestErrorPositions(t *testing.T) {
+	cfg, err := ymlcfg.FromText([]byte(data))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+
+	if pos := cfg.Get("c").Pos(); pos != (ymlcfg.Pos{Line: 4, Column: 4}) {
+		t.Fatalf("Expected [.c] to be at 4:4 but got %v", pos)
+	}
+
+	nested := `
+sources:
+  a: src
+  b: [src/b, [nested]]
+`
+	cfg, err = ymlcfg.FromText([]byte(nested))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+	_, err = ymlcfg.BindMap(cfg.Get("sources"), ymlcfg.ConfigValue.BindStrSeq)
+	if err == nil || err.Error() != "4:14: sources.b[1]: expected string" {
+		t.Fatalf("Expected an error at 4:14 for [.sources.b[1]] but got %v", err)
+	}
+
+	if err := cfg.Get("sources").Get("c").Errorf("missing"); err.Error() != "3:3: sources.c: missing" {
+		t.Fatalf("Expected missing values to be at the position of their mapping but got %v", err)
+	}
+}
+
+func TestUnused(t *testing.T) {
+	cfg, err := ymlcfg.FromText([]byte(data))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+
+	cfg.Get("a").BindStr()
+	cfg.Get("e").BindStrSeq()
+	cfg.Get("d").Get("x").BindStr()
+	ymlcfg.BindMap(cfg.Get("d"), ymlcfg.ConfigValue.BindStr)
+
+	unused := ymlcfg.Unused(cfg)
+	if len(unused) != 2 {
+		t.Fatalf("Expected 2 unused keys but got %v", unused)
+	}
+	if unused[0].Path != "b" || unused[0].Line != 3 || unused[0].Column != 1 || unused[0].Suggestion != "" {
+		t.Fatalf("Expected [.b] to be unused at 3:1 without suggestion but got %v", unused[0])
+	}
+	if unused[1].String() != "4:1: unknown key c" {
+		t.Fatalf("Expected [.c] to be unused at 4:1 but got %q", unused[1].String())
+	}
+}
+
+var typed = `
+width: 120
+tabs: yes
+mode: fast
+nested:
+  count: -4
+  names: [a, b]
+timeout: 1m30s
+`
+
+func TestBindScalars(t *testing.T) {
+	cfg, err := ymlcfg.FromText([]byte(typed))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+
+	if width, err := ymlcfg.BindInt(cfg.Get("width"), 80, 1, 200); err != nil || width != 120 {
+		t.Fatalf("Expected [.width] to be 120 but got %v (%v)", width, err)
+	}
+	if width, err := ymlcfg.BindInt(cfg.Get("missing"), 80, 1, 200); err != nil || width != 80 {
+		t.Fatalf("Expected missing integers to be the default but got %v (%v)", width, err)
+	}
+	_, err = ymlcfg.BindInt(cfg.Get("width"), 80, 1, 100)
+	if err == nil || err.Error() != `2:8: width: expected an integer between 1 and 100, but got "120"` {
+		t.Fatalf("Expected [.width] to be out of range but got %v", err)
+	}
+
+	_, err = ymlcfg.BindBool(cfg.Get("tabs"), false)
+	if err == nil || err.Error() != "3:7: tabs: expected true or false" {
+		t.Fatalf("Expected [.tabs] not to be a boolean but got %v", err)
+	}
+
+	modes := []ymlcfg.EnumValue[int]{{Value: 1, Names: []string{"slow"}}, {Value: 2, Names: []string{"fast", "quick"}}}
+	if mode, err := ymlcfg.BindEnum(cfg.Get("mode"), 0, modes...); err != nil || mode != 2 {
+		t.Fatalf("Expected [.mode] to be 2 but got %v (%v)", mode, err)
+	}
+	_, err = ymlcfg.BindEnum(cfg.Get("width"), 0, modes...)
+	if err == nil || err.Error() != "2:8: width: expected one of slow or fast" {
+		t.Fatalf("Expected [.width] not to be a mode but got %v", err)
+	}
+}
+
+func TestBindDuration(t *testing.T) {
+	cfg, err := ymlcfg.FromText([]byte(typed))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+
+	timeout, err := ymlcfg.BindDuration(cfg.Get("timeout"), time.Second, 0, math.MaxInt64)
+	if err != nil || timeout != 90*time.Second {
+		t.Fatalf("Expected [.timeout] to be 1m30s but got %v (%v)", timeout, err)
+	}
+	if timeout, err := ymlcfg.BindDuration(cfg.Get("missing"), time.Second, 0, math.MaxInt64); err != nil ||
+		timeout != time.Second {
+		t.Fatalf("Expected missing durations to be the default but got %v (%v)", timeout, err)
+	}
+	_, err = ymlcfg.BindDuration(cfg.Get("timeout"), time.Second, 0, time.Minute)
+	if err == nil || err.Error() != `8:10: timeout: expected a duration like 1m30s between 0s and 1m0s, but got "1m30s"` {
+		t.Fatalf("Expected [.timeout] to be out of range but got %v", err)
+	}
+	_, err = ymlcfg.BindDuration(cfg.Get("mode"), time.Second, math.MinInt64, math.MaxInt64)
+	if err == nil || err.Error() != `4:7: mode: expected a duration like 1m30s, but got "fast"` {
+		t.Fatalf("Expected [.mode] not to be a duration but got %v", err)
+	}
+
+	var target struct {
+		Timeout time.Duration `ymlcfg:"timeout" max:"1h"`
+		Delay   time.Duration `ymlcfg:"delay" default:"500ms"`
+	}
+	if err := ymlcfg.Bind(cfg, &target); err != nil || target.Timeout != 90*time.Second ||
+		target.Delay != 500*time.Millisecond {
+		t.Fatalf("Expected the durations to be bound but got %+v (%v)", target, err)
+	}
+	schema := ymlcfg.Schema(target)["properties"].(map[string]any)["delay"].(map[string]any)
+	if schema["type"] != "string" || schema["default"] != "500ms" {
+		t.Fatalf("Expected durations to be strings in the schema but got %v", schema)
+	}
+}
+
+func TestBindStruct(t *testing.T) {
+	cfg, err := ymlcfg.FromText([]byte(typed))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+
+	var target struct {
+		Width  int    `ymlcfg:"width" default:"80" min:"1"`
+		Mode   uint8  `ymlcfg:"mode" enum:"slow,fast|quick"`
+		Title  string `ymlcfg:"title" default:"untitled"`
+		Nested struct {
+			Count int      `ymlcfg:"count" min:"0"`
+			Names []string `ymlcfg:"names"`
+		} `ymlcfg:"nested"`
+		Ignored string
+	}
+	err = ymlcfg.Bind(cfg, &target)
+	if err == nil || err.Error() != `6:10: nested.count: expected an integer greater than or equal to 0, but got "-4"` {
+		t.Fatalf("Expected an error at [.nested.count] but got %v", err)
+	}
+	if target.Width != 120 || target.Mode != 1 || target.Title != "untitled" || len(target.Nested.Names) != 2 {
+		t.Fatalf("Expected the other fields to be bound but got %+v", target)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	var target struct {
+		Width  int      `ymlcfg:"width" default:"80" min:"1" doc:"The width."`
+		Mode   uint8    `ymlcfg:"mode" enum:"slow,fast|quick"`
+		Names  []string `ymlcfg:"names"`
+		Nested struct {
+			Enabled bool `ymlcfg:"enabled" default:"true"`
+		} `ymlcfg:"nested"`
+		Ignored string
+	}
+	data, err := json.Marshal(ymlcfg.Schema(target))
+	if err != nil {
+		t.Fatalf("error marshalling the schema: %s", err)
+	}
+	expected := `{"additionalProperties":false,"properties":{` +
+		`"mode":{"default":"slow","enum":["slow","fast","quick"],"type":"string"},` +
+		`"names":{"anyOf":[{"type":"string"},{"items":{"type":"string"},"type":"array"}]},` +
+		`"nested":{"additionalProperties":false,"properties":{"enabled":{"default":true,"type":"boolean"}},"type":"object"},` +
+		`"width":{"default":80,"description":"The width.","minimum":1,"type":"integer"}},"type":"object"}`
+	if string(data) != expected {
+		t.Fatalf("expected schema %s but got %s", expected, data)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base, err := ymlcfg.FromText([]byte("a: 1\nb:\n  x: 1\n  y: [1, 2]\n"))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+	override, err := ymlcfg.FromText([]byte("b:\n  y: 3\n  z: 3\nc: 3\n"))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+	cfg := ymlcfg.Merge(base, override)
+
+	var keys []string
+	cfg.Get("b").BindMap(func(key string, value ymlcfg.ConfigValue) error {
+		str, _ := value.BindStr()
+		keys = append(keys, key+"="+str)
+		return nil
+	})
+	if strings.Join(keys, ",") != "x=1,y=3,z=3" {
+		t.Fatalf("expected b to be merged but got %v", keys)
+	}
+	a, err := cfg.Get("a").BindStr()
+	if err != nil || a != "1" || cfg.Get("a").Pos() != (ymlcfg.Pos{Line: 1, Column: 4}) {
+		t.Fatalf("expected a to be kept from the base but got %q at %v (%v)", a, cfg.Get("a").Pos(), err)
+	}
+	if unused := ymlcfg.Unused(cfg); len(unused) != 1 || unused[0].Path != "c" {
+		t.Fatalf("expected c to be unused but got %v", unused)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	cfg, err := ymlcfg.FromText([]byte("a: ${HOME}/x\nb: [\"${EMPTY:-def}\", \"$${HOME}\"]\nc: ${HOME\n"))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+	env := map[string]string{"HOME": "/home", "EMPTY": ""}
+	cfg, err = ymlcfg.Interpolate(cfg, func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+	if err == nil || err.Error() != "3:4: c: unterminated variable, expected a closing brace" {
+		t.Fatalf("expected an error at [.c] but got %v", err)
+	}
+	if a, _ := cfg.Get("a").BindStr(); a != "/home/x" {
+		t.Fatalf("expected a to be /home/x but got %q", a)
+	}
+	if b, _ := cfg.Get("b").BindStrSeq(); len(b) != 2 || b[0] != "def" || b[1] != "${HOME}" {
+		t.Fatalf("expected b to be [def ${HOME}] but got %v", b)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	cfg, err := ymlcfg.FromText([]byte(data))
+	if err != nil {
+		t.Fatalf("error parsing yaml data: %s", err)
+	}
+	cfg, err = ymlcfg.Override(cfg, "d.y", "[4, 5]", "--config d.y=[4, 5]")
+	if err != nil {
+		t.Fatalf("error overriding d.y: %s", err)
+	}
+	if y, err := cfg.Get("d").Get("y").BindStrSeq(); err != nil || len(y) != 2 || y[1] != "5" {
+		t.Fatalf("expected d.y to be [4 5] but got %v (%v)", y, err)
+	}
+	if x, err := cfg.Get("d").Get("x").BindStr(); err != nil || x != "1" {
+		t.Fatalf("expected d.x to be kept but got %q (%v)", x, err)
+	}
+	_, err = cfg.Get("d").Get("y").BindStr()
+	if err == nil || err.Error() != "--config d.y=[4, 5]: d.y: expected string" {
+		t.Fatalf("expected an error from the override but got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package ymlcfg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A key of a mapping that was never read.
+type UnusedKey struct {
+	// The path of the file defining the key, which may be empty, or where the key comes from if it was not read from a
+	// file (the line and column are zero in that case).
+	File string
+	// The full path of the key, like "format.printWidth".
+	Path   string
+	Line   int
+	Column int
+	// The key that was looked up in the same mapping and is the closest to this one, or an empty string if none is
+	// close enough.
+	Suggestion string
+}
+
+func (key UnusedKey) String() string {
+	msg := "unknown key " + key.Path
+	if key.Line > 0 {
+		msg = fmt.Sprintf("%d:%d: %s", key.Line, key.Column, msg)
+	}
+	if key.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", key.Suggestion)
+	}
+	return msg
+}
+
+// Return the keys of the mappings in [cfg] that were not read with Get or BindMap, in the order of the documents.
+// This should be called once all the values of the configuration have been read.
+func Unused(cfg ConfigValue) []UnusedKey {
+	var unused []UnusedKey
+	collectUnused(cfg, "", &unused)
+	slices.SortFunc(unused, func(a, b UnusedKey) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return unused
+}
+
+func collectUnused(cfg ConfigValue, path string, unused *[]UnusedKey) {
+	switch cfg := cfg.(type) {
+	case *configDocument:
+		if cfg.content != nil {
+			collectUnused(cfg.content, path, unused)
+		}
+	case *configSequence:
+		for i, value := range cfg.values {
+			collectUnused(value, fmt.Sprintf("%s[%d]", path, i), unused)
+		}
+	case *configMapping:
+		for key, entry := range cfg.entries {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if entry.bound {
+				collectUnused(entry.value, keyPath, unused)
+			} else {
+				*unused = append(*unused, UnusedKey{
+					File:       entry.file,
+					Path:       keyPath,
+					Line:       entry.keyNode.Line,
+					Column:     entry.keyNode.Column,
+					Suggestion: closestKey(key, cfg.requested),
+				})
+			}
+		}
+	}
+}
+
+// Return the candidate closest to [key], if the distance between them is small enough to be a typo.
+func closestKey(key string, candidates []string) string {
+	best, bestDistance := "", max(1, len(key)/3)
+	for _, candidate := range candidates {
+		d := editDistance(strings.ToLower(key), strings.ToLower(candidate))
+		if d <= bestDistance && d < len(key) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// The Levenshtein distance between two strings, where swapping two adjacent characters counts as one edit.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// rows i-2, i-1 and i of the distance matrix
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+// Describe the commands of elz, their flags and their arguments.
+func commandRegistry() *cli.Command {
+	return &cli.Command{
+		Name:        "elz",
+		Description: "Elz is a tool for managing Elizalina translations.",
+		Usage:       []string{"<command> [arguments]"},
+		Notes: []string{"In a directory containing " + project.StdWorkspaceFileName + ", commands working on a " +
+			"project are run in every project of the workspace, listed by the patterns of its projects key (all the " +
+			"projects below it by default)."},
+		Flags: []*cli.Flag{
+			{
+				Name: "project", Value: "dir", Complete: completeProjectDirs,
+				Usage: "Use the project in this directory instead of the one containing the working directory.",
+			},
+			{Name: "recursive", Shorthand: "r", Usage: "Run the command in every project below the working directory."},
+			{
+				Name: "jobs", Shorthand: "j", Value: "n",
+				Usage: "With a workspace or --recursive, the number of projects processed at the same time (the " +
+					"number of processors by default).",
+			},
+			{
+				Name: "config", Value: "key=val", Complete: completeConfigKeys,
+				Usage: "Override a key of " + project.StdConfigFileName + ", like format.printWidth=100 (can be " +
+					"specified multiple times). Keys can also be overridden with environment variables like " +
+					"ELZ_FORMAT_PRINTWIDTH.",
+			},
+			{
+				Name: "output", Value: "text|json", Complete: completeValues(cli.OutputFormats...),
+				Usage: "The format of the output. With json, every result and diagnostic is written to the standard " +
+					"output as a JSON object on its own line, with a type and a schemaVersion field.",
+			},
+			{
+				Name: "help", Shorthand: "h",
+				Usage: "Show command usage and exit. Run 'elz --help <command>' to get help for a specific command.",
+			},
+			{
+				Name:  "no-color",
+				Usage: "Disable colored output. This option can also be set via the NO_COLOR environment variable.",
+			},
+			{Name: "version", Shorthand: "v", Usage: "Print the tool version and exit."},
+			{Name: "debug", Hidden: true},
+		},
+		Subcommands: []*cli.Command{
+			initCommand(),
+			localeCommand(),
+			prefixCommand(),
+			messageCommand(),
+			updateCommand(),
+			releaseCommand(),
+			formatCommand(),
+			completionCommand(),
+			helpCommand(),
+			configCommand(),
+		},
+	}
+}
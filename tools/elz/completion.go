@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+func cmdCompletion(args cli.Args) {
+	shell, err := args.Positional("shell")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	script, err := cli.CompletionScript("elz", shell)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	fmt.Print(script)
+}
+
+// Print the candidates for the last of [words], called by the completion scripts. Nothing is printed on errors, since
+// the output would be taken as candidates.
+func cmdComplete(words []string) {
+	for _, candidate := range cli.Complete(commandRegistry(), words) {
+		fmt.Println(candidate)
+	}
+}
+
+// Load the translations of the project containing the working directory for completion, or return <nil> if there is
+// no project. Invalid files are left out.
+func completionCatalog() (project.Config, *lang.Catalog) {
+	path, found := project.FindConfigFile(".")
+	if !found {
+		return nil, nil
+	}
+	cfg, err := project.LoadConfigFile(path)
+	if err != nil {
+		return nil, nil
+	}
+	dir, err := cfg.Translations()
+	if err != nil || dir == "" {
+		return cfg, nil
+	}
+	catalog, _ := lang.LoadDir(filepath.Join(cfg.Dir(), dir))
+	return cfg, catalog
+}
+
+// Complete the locales of the project.
+func completeLocales() []string {
+	cfg, catalog := completionCatalog()
+	if catalog == nil {
+		return nil
+	}
+	locales := catalog.Locales()
+	if source, err := cfg.SourceLocale(); err == nil && source != "" && !slices.Contains(locales, source) {
+		locales = append(locales, source)
+	}
+	return locales
+}
+
+// Complete the prefixes of the project, from its translation files and its sources.
+func completePrefixes() []string {
+	cfg, catalog := completionCatalog()
+	if cfg == nil {
+		return nil
+	}
+	var prefixes []string
+	if catalog != nil {
+		prefixes = catalog.Prefixes()
+	}
+	if sources, err := cfg.Sources(); err == nil {
+		for prefix := range sources {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	prefixes = slices.DeleteFunc(prefixes, func(prefix string) bool { return prefix == project.NoPrefix })
+	slices.Sort(prefixes)
+	return slices.Compact(prefixes)
+}
+
+// Complete the keys of the messages of the project, written like in elz message.
+func completeKeys() []string {
+	_, catalog := completionCatalog()
+	if catalog == nil {
+		return nil
+	}
+	var keys []string
+	for _, file := range catalog.Files {
+		for _, msg := range file.Messages {
+			if file.Prefix == project.NoPrefix {
+				keys = append(keys, msg.Key)
+			} else {
+				keys = append(keys, file.Prefix+"."+msg.Key)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// Complete the keys of the configuration file, followed by an equal sign.
+func completeConfigKeys() []string {
+	var keys []string
+	for _, key := range project.ConfigKeys() {
+		if key != "extends" {
+			keys = append(keys, key+"=")
+		}
+	}
+	return keys
+}
+
+// Complete the directories containing a configuration file, below the working directory.
+func completeProjectDirs() []string {
+	configs, err := project.FindProjects(".", nil)
+	if err != nil {
+		return nil
+	}
+	dirs := make([]string, len(configs))
+	for i, config := range configs {
+		dirs[i] = filepath.ToSlash(filepath.Dir(config))
+	}
+	return dirs
+}
+
+// Complete a fixed list of values.
+func completeValues(values ...string) func() []string {
+	return func() []string {
+		return slices.Clone(values)
+	}
+}
+
+func completionCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "completion",
+		Summary:     "Generate shell completion scripts",
+		Description: "Elz completion prints a script that completes the commands of elz in a shell.",
+		Usage:       []string{"<" + strings.Join(cli.CompletionShells, "|") + ">"},
+		Details:     []string{"Inside of a project, locales, prefixes and message keys are completed too."},
+		Sections: []cli.Section{{Title: "Installation", Entries: []cli.Entry{
+			{Name: "bash", Description: "Add 'source <(elz completion bash)' to ~/.bashrc."},
+			{Name: "zsh", Description: "Add 'source <(elz completion zsh)' to ~/.zshrc, after compinit."},
+			{Name: "fish", Description: "Run 'elz completion fish > ~/.config/fish/completions/elz.fish'."},
+		}}},
+		Args: []*cli.Arg{{Name: "shell", Complete: completeValues(cli.CompletionShells...)}},
+		Run:  cmdCompletion,
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+
+	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+func cmdConfigSchema(args cli.Args) {
+	args.Done()
+
+	schema, err := project.JSONSchema()
+	if err != nil {
+		cli.Fatal("could not generate the schema", cli.InternalError, err)
+	}
+	os.Stdout.Write(schema)
+}
+
+// This command is not listed in the main help, it is mostly used to keep docs/elz.schema.json up to date.
+func configCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "config",
+		Summary:     "Give information about the configuration file",
+		Description: "Elz config gives information about the configuration file.",
+		Hidden:      true,
+		Subcommands: []*cli.Command{{
+			Name: "schema",
+			Summary: "Print the JSON schema of " + project.StdConfigFileName + ". Editors using the YAML language " +
+				"server can validate the configuration with the schema in docs/elz.schema.json.",
+			Run: cmdConfigSchema,
+		}},
+	}
+}
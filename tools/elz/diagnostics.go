@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/ymlcfg"
+)
+
+// The codes of the diagnostics reported by elz. A code always designates the same kind of problem, new kinds of
+// problems get new codes.
+const (
+	// A translation file cannot be parsed.
+	codeSyntax = "ELZ0001"
+	// A value of the configuration is invalid.
+	codeConfigValue = "ELZ0002"
+	// A key of the configuration is not used.
+	codeUnknownKey = "ELZ0003"
+	// A message of the translation files is not used in the sources.
+	codeUnusedMessage = "ELZ0004"
+	// A call to the translate function in the sources cannot be extracted.
+	codeInvalidCall = "ELZ0005"
+	// A translation file is not formatted.
+	codeUnformatted = "ELZ0006"
+	// A translation file is missing messages used in the sources, or has unused messages to prune.
+	codeOutdated = "ELZ0007"
+	// A message will be left out of the generated code.
+	codeNotReleased = "ELZ0008"
+	// The translations of the locales do not match.
+	codeInconsistent = "ELZ0009"
+)
+
+// Return a diagnostic for [err]. Syntax and configuration errors get their code, and their position becomes the span
+// of the diagnostic.
+func diagnostic(level cli.Level, err error) cli.Diagnostic {
+	var (
+		syntaxErr *lang.SyntaxError
+		configErr *ymlcfg.Error
+	)
+	switch {
+	case errors.As(err, &syntaxErr) && syntaxErr.Path != "":
+		return cli.Diagnostic{
+			Level: level, Code: codeSyntax, Message: syntaxErr.Msg,
+			Span: &cli.Span{File: syntaxErr.Path, Line: syntaxErr.Pos.Line, Column: syntaxErr.Pos.Column},
+		}
+	case errors.As(err, &configErr) && configErr.File != "":
+		if configErr.Pos.Line == 0 {
+			// the value comes from an override like --config, its origin is kept in the message
+			return cli.Diagnostic{Level: level, Code: codeConfigValue, Message: configErr.Error()}
+		}
+		msg := configErr.Msg
+		if configErr.Path != "" {
+			msg = configErr.Path + ": " + msg
+		}
+		return cli.Diagnostic{
+			Level: level, Code: codeConfigValue, Message: msg,
+			Span: &cli.Span{File: configErr.File, Line: configErr.Pos.Line, Column: configErr.Pos.Column},
+		}
+	default:
+		return cli.Diagnostic{Level: level, Message: err.Error()}
+	}
+}
+
+// Report each of the errors joined in [err] as a diagnostic.
+func reportAll(level cli.Level, err error) {
+	for _, single := range flattenErrors(err) {
+		cli.Report(diagnostic(level, single))
+	}
+}
+
+// Return the errors joined in [err], recursively.
+func flattenErrors(err error) []error {
+	multi, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, wrapped := range multi.Unwrap() {
+		errs = append(errs, flattenErrors(wrapped)...)
+	}
+	return errs
+}
+
+// Report the errors of the configuration joined in [err] and exit.
+func abortInvalidConfig(err error) {
+	for _, single := range flattenErrors(err) {
+		d := diagnostic(cli.LevelError, single)
+		if d.Code == "" {
+			d.Message = "invalid configuration: " + d.Message
+		}
+		cli.Report(d)
+	}
+	cli.Abort(cli.UserError)
+}
@@ -1,27 +1,211 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
 )
 
-func cmdFormat(arg cli.Args) {
-	cli.Fatal("Command \"format\" not implemented", cli.InternalError)
+// The result of formatting a file with elz format, as written with --output=json. The formatted content is only given
+// when the files are neither checked nor written.
+type formatRecord struct {
+	Path string `json:"path"`
+	// wether the file was already formatted
+	Formatted bool    `json:"formatted"`
+	Written   bool    `json:"written"`
+	Content   *string `json:"content,omitempty"`
 }
 
-func showFormatHelp() {
-	cli.ShowUsage(
-		"Elz format enforces the project's format rules in translation files.",
-		"elz format --check [<file> ...]",
-		"elz format [--write] [<file> ...]",
+func cmdFormat(args cli.Args) {
+	check, err := args.BoolFlag("check", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	write, err := args.BoolFlag("write", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	locales, err := args.StringSliceFlag("locale", "L")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	prefixes, err := args.StringSliceFlag("prefix", "P")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	scoped := scopedFormatFlags(args)
+	files := args.Rest()
+	args.Done()
+
+	if check && write {
+		cli.InvalidArgs(errors.New("Flags \"--check\" and \"--write\" cannot be used together"))
+	}
+
+	cfg := loadProject()
+	var (
+		optsByPrefix = make(map[string]lang.FormatOptions)
+		sourceKeys   map[string][]string
 	)
-	cli.Show("\nAlias: format, fmt")
-	cli.Show("\nIf no files are specified, all translations files in the project will be formatted. " +
-		"A single dash \"-\" can be used to read from the standard input instead.")
-	cli.Show("\nOptions:")
-	cli.DescribeOption("-L, --locale <loc>", "Target only files with this locale (can be specified multiple times).")
-	cli.DescribeOption("-P, --prefix <pre>", "Target only files with this prefix (can be specified multiple times).")
-	cli.DescribeOption("--check           ",
-		"Assert that all files are properly formatted, or fails with a summary of the files to reformat.")
-	cli.DescribeOption("--write           ", "Rewrite the files in place instead of printing to the standard ouput.")
-	showGlobalOptions()
-}
\ No newline at end of file
+	// the options of a prefix are read the first time a file of this prefix is formatted
+	prefixOptions := func(prefix string) lang.FormatOptions {
+		if opts, ok := optsByPrefix[prefix]; ok {
+			return opts
+		}
+		prefixCfg, err := project.WithOverrides(cfg, slices.Concat(scoped[""], scoped[prefix])...)
+		if err != nil {
+			cli.Fatal("invalid format options", cli.UserError, err)
+		}
+		opts := formatOptions(prefixCfg)
+		if sourceKeys == nil {
+			sourceKeys = sourceOrder(cfg, opts)
+		}
+		if sourceKeys != nil {
+			opts.SourceOrder = sourceKeys[prefix]
+		}
+		optsByPrefix[prefix] = opts
+		return opts
+	}
+
+	if len(files) == 0 {
+		files, err = filepath.Glob(filepath.Join(translationsDir(cfg), "*"+lang.Extension))
+		if err != nil {
+			cli.Fatal("could not list translation files", cli.InternalError, err)
+		}
+	}
+
+	for _, path := range files {
+		if path != "-" {
+			if prefix, locale, err := lang.ParseFileName(path); err == nil &&
+				!matchesFilters(prefix, locale, prefixes, locales) {
+				continue
+			}
+		}
+
+		name := displayPath(path)
+		if path == "-" {
+			name = "<stdin>"
+		}
+		src, err := readSource(path)
+		if err != nil {
+			cli.Error("could not read "+name, err)
+			continue
+		}
+		file, err := lang.Parse(name, src)
+		if err != nil {
+			reportAll(cli.LevelError, err)
+			continue
+		}
+
+		// files read from the standard input only use the options without prefix
+		prefix := ""
+		if parsed, _, err := lang.ParseFileName(path); path != "-" && err == nil {
+			prefix = parsed
+		}
+		formatted := lang.Format(file, prefixOptions(prefix))
+		record := formatRecord{Path: name, Formatted: bytes.Equal(src, formatted)}
+		switch {
+		case check:
+			if !record.Formatted {
+				cli.Report(cli.Diagnostic{
+					Level: cli.LevelError, Code: codeUnformatted, Message: name + " is not formatted",
+					Span: &cli.Span{File: name}, Hints: []string{"run elz format --write to reformat it"},
+				})
+			}
+		case write && path != "-":
+			if !record.Formatted {
+				cli.Debug("reformatting", path)
+				if err := os.WriteFile(path, formatted, 0666); err != nil {
+					cli.Error("could not write "+name, err)
+					continue
+				}
+				record.Written = true
+			}
+		case cli.DefaultPrinter().Output == cli.JSONOutput:
+			content := string(formatted)
+			record.Content = &content
+		default:
+			os.Stdout.Write(formatted)
+		}
+		cli.Emit("format", record)
+	}
+}
+
+// Read the flags overriding the format section, like --format.printWidth=100, which can be scoped to a prefix with
+// --format.printWidth:ui=100. The overrides are returned by prefix, the ones for all prefixes having an empty prefix.
+func scopedFormatFlags(args cli.Args) map[string][]project.Override {
+	scoped := make(map[string][]project.Override)
+	for _, key := range project.ConfigKeys() {
+		if !strings.HasPrefix(key, "format.") {
+			continue
+		}
+		values, err := args.ScopedStringFlag(key, "")
+		if err != nil {
+			cli.InvalidArgs(err)
+		}
+		for prefix, value := range values {
+			origin := "--" + key + "=" + value
+			if prefix != "" {
+				origin = "--" + key + ":" + prefix + "=" + value
+			}
+			scoped[prefix] = append(scoped[prefix], project.Override{Key: key, Value: value, Origin: origin})
+		}
+	}
+	return scoped
+}
+
+// Read a file, or the standard input if [path] is "-".
+func readSource(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+func formatCommand() *cli.Command {
+	flags := []*cli.Flag{
+		{
+			Name: "locale", Shorthand: "L", Value: "loc", Complete: completeLocales,
+			Usage: "Target only files with this locale (can be specified multiple times).",
+		},
+		{
+			Name: "prefix", Shorthand: "P", Value: "pre", Complete: completePrefixes,
+			Usage: "Target only files with this prefix (can be specified multiple times).",
+		},
+		{
+			Name:  "check",
+			Usage: "Assert that all files are properly formatted, or fails with a summary of the files to reformat.",
+		},
+		{Name: "write", Usage: "Rewrite the files in place instead of printing to the standard ouput."},
+	}
+	for _, key := range project.ConfigKeys() {
+		if strings.HasPrefix(key, "format.") {
+			flags = append(flags, &cli.Flag{
+				Name: key, Value: "value", Scope: "prefix", Scopes: completePrefixes, Hidden: true,
+			})
+		}
+	}
+
+	return &cli.Command{
+		Name:        "format",
+		Aliases:     []string{"fmt"},
+		Summary:     "Format translation files",
+		Description: "Elz format enforces the project's format rules in translation files.",
+		Usage:       []string{"--check [<file> ...]", "[--write] [<file> ...]"},
+		Details: []string{"If no files are specified, all translations files in the project will be formatted. A " +
+			"single dash \"-\" can be used to read from the standard input instead."},
+		Notes: []string{"Every key of the format section can be overridden with a flag like " +
+			"--format.printWidth=100. Add a prefix to the flag to only override the key for the files of this " +
+			"prefix, like --format.printWidth:ui=100."},
+		Flags: flags,
+		Args:  []*cli.Arg{{Name: "file", Optional: true, Variadic: true}},
+		Run:   cmdFormat,
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/louisdevie/elizalina2/internal/cli"
+)
+
+func cmdHelp(args cli.Args) {
+	man, err := args.BoolFlag("man", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	markdown, err := args.BoolFlag("markdown", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	dir, err := args.StringFlag("dir", "", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	names := args.Rest()
+	args.Done()
+
+	if man && markdown {
+		cli.InvalidArgs(errors.New("Flags \"--man\" and \"--markdown\" cannot be used together"))
+	}
+	if dir != "" && !man && !markdown {
+		cli.InvalidArgs(errors.New("Flag \"--dir\" requires \"--man\" or \"--markdown\""))
+	}
+
+	path := commandRegistry().Lookup(names...)
+	var (
+		render    func(path []*cli.Command) string
+		extension string
+	)
+	switch {
+	case man:
+		render = func(path []*cli.Command) string { return cli.ManPage(path, elzVersion) }
+		extension = ".1"
+	case markdown:
+		render = cli.MarkdownPage
+		extension = ".md"
+	default:
+		cli.ShowHelp(path)
+		return
+	}
+
+	if dir == "" {
+		fmt.Print(render(path))
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		cli.Fatal("could not create "+dir, cli.UserError, err)
+	}
+	for _, page := range cli.AllPages(path) {
+		file := filepath.Join(dir, cli.PageName(page)+extension)
+		if err := os.WriteFile(file, []byte(render(page)), 0o644); err != nil {
+			cli.Fatal("could not write "+file, cli.UserError, err)
+		}
+		cli.Show("created " + file)
+	}
+}
+
+func helpCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "help",
+		Summary:     "Show the help of a command or generate reference pages",
+		Description: "Elz help shows the help of a command, or renders it as a man page or in Markdown.",
+		Details: []string{"The pages are generated from the same definitions as the help, so that packaged man " +
+			"pages and documentation match the binary. Without --dir, the page of the command is printed to the " +
+			"standard output."},
+		Flags: []*cli.Flag{
+			{Name: "man", Usage: "Write man pages (section 1) in roff."},
+			{Name: "markdown", Usage: "Write Markdown pages, linked to each other."},
+			{
+				Name: "dir", Value: "dir",
+				Usage: "Write a page for the command and each of its subcommands in this directory, like " +
+					"elz-locale-add.1 or elz-locale-add.md.",
+			},
+		},
+		Args: []*cli.Arg{{Name: "command", Optional: true, Variadic: true}},
+		Run:  cmdHelp,
+	}
+}
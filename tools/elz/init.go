@@ -1,20 +1,288 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/glob"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
 )
 
+// What elz init found in the working directory.
+type detectedProject struct {
+	goModule   bool
+	npmPackage bool
+	// wether package.json has "type": "module"
+	esModule bool
+	hasSrc   bool
+}
+
+func detectProject() (detected detectedProject) {
+	if _, err := os.Stat("go.mod"); err == nil {
+		detected.goModule = true
+		cli.Debug("found go.mod")
+	}
+	if data, err := os.ReadFile("package.json"); err == nil {
+		detected.npmPackage = true
+		var fields struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(data, &fields) == nil {
+			detected.esModule = fields.Type == "module"
+		}
+		cli.Debug("found package.json, type is", fields.Type)
+	}
+	if info, err := os.Stat("src"); err == nil && info.IsDir() {
+		detected.hasSrc = true
+	}
+	return detected
+}
+
 func cmdInit(args cli.Args) {
-	cli.DefaultPrinter().Program = "elz init"
+	yes, err := args.BoolFlag("yes", "y", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	force, err := args.BoolFlag("force", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	sourceLocaleFlag, err := args.StringFlag("source-locale", "", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	locales, err := args.StringSliceFlag("locale", "L")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	sources, err := args.StringSliceFlag("source", "S")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	translations, err := args.StringFlag("translations", "", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	goOutput, err := args.StringFlag("go-output", "", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	goPackage, err := args.StringFlag("go-package", "", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	jsOutput, err := args.StringFlag("js-output", "", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	jsModule, err := args.EnumFlag("js-module", "", "", "esm", "cjs", "commonjs")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
 	args.Done()
 
-	
+	if _, err := os.Stat(project.StdConfigFileName); err == nil && !force {
+		cli.Fatal(project.StdConfigFileName+" already exists (use --force to overwrite it)", cli.UserError)
+	}
+
+	// the flags are the default answers, and they are completed with what is found in the working directory
+	detected := detectProject()
+	settings := project.Settings{
+		SourceLocale: orDefault(sourceLocaleFlag, "en"),
+		Sources:      sources,
+		Translations: orDefault(translations, "translations"),
+		GoOutput:     goOutput,
+		GoPackage:    goPackage,
+		JSOutput:     jsOutput,
+		JSModule:     project.CommonJS,
+	}
+	locales = splitList(locales)
+	if len(settings.Sources) == 0 {
+		if detected.hasSrc {
+			settings.Sources = []string{"src"}
+		} else {
+			settings.Sources = []string{"."}
+		}
+	}
+	if settings.GoOutput == "" && detected.goModule {
+		settings.GoOutput = "internal/messages"
+	}
+	if settings.JSOutput == "" && detected.npmPackage {
+		settings.JSOutput = "i18n"
+		if detected.hasSrc {
+			settings.JSOutput = "src/i18n"
+		}
+	}
+	if jsModule == "esm" || jsModule == "" && detected.esModule {
+		settings.JSModule = project.ESModule
+	}
+
+	if !yes {
+		if cli.GetStdinInfo().IsTTY {
+			askSettings(&settings, &locales)
+		} else {
+			cli.Warning("the standard input is not a terminal, using the default answers (use --yes to hide this " +
+				"warning)")
+		}
+	}
+
+	// generated code is never scanned for messages
+	if detected.npmPackage {
+		settings.Ignore = append(settings.Ignore, "node_modules")
+	}
+	for _, output := range []string{settings.GoOutput, settings.JSOutput} {
+		if output != "" {
+			settings.Ignore = append(settings.Ignore, filepath.ToSlash(filepath.Clean(output)))
+		}
+	}
+
+	if errs := checkSettings(settings, locales); len(errs) > 0 {
+		cli.Fatal("invalid settings", cli.UserError, errs...)
+	}
+
+	if err := os.WriteFile(project.StdConfigFileName, project.NewConfigText(settings), 0666); err != nil {
+		cli.Fatal("could not write "+project.StdConfigFileName, cli.UserError, err)
+	}
+	reportFile("created", project.StdConfigFileName)
+
+	if err := os.MkdirAll(settings.Translations, 0777); err != nil {
+		cli.Fatal("could not create "+settings.Translations, cli.UserError, err)
+	}
+	for _, locale := range append([]string{settings.SourceLocale}, locales...) {
+		path := filepath.Join(settings.Translations, lang.FileName(project.NoPrefix, locale))
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.WriteFile(path, nil, 0666); err != nil {
+			cli.Fatal("could not create "+path, cli.UserError, err)
+		}
+		reportFile("created", path)
+	}
+	cli.Show("\nRun 'elz update' to add the messages used in the sources to the translation files.")
+}
+
+// Return [value], or [def] if it is empty.
+func orDefault(value string, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// Split values separated by commas.
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// Ask for each setting, using the current values as default answers.
+func askSettings(settings *project.Settings, locales *[]string) {
+	settings.SourceLocale = cli.Ask("Locale the messages are written in", settings.SourceLocale)
+	*locales = cli.AskList("Locales to translate the messages to (separated by commas)", *locales)
+	settings.Sources = cli.AskList("Source files or patterns to extract messages from", settings.Sources)
+	settings.Translations = cli.Ask("Directory of the translation files", settings.Translations)
+
+	if cli.Confirm("Generate a Go package?", settings.GoOutput != "") {
+		settings.GoOutput = cli.Ask("Directory of the Go package", orDefault(settings.GoOutput, "internal/messages"))
+		settings.GoPackage = cli.Ask("Name of the Go package", orDefault(settings.GoPackage,
+			filepath.Base(settings.GoOutput)))
+		if settings.GoPackage == filepath.Base(settings.GoOutput) {
+			// this is the default value and does not need to be written
+			settings.GoPackage = ""
+		}
+	} else {
+		settings.GoOutput = ""
+	}
+
+	if cli.Confirm("Generate JavaScript modules?", settings.JSOutput != "") {
+		settings.JSOutput = cli.Ask("Directory of the JavaScript modules", orDefault(settings.JSOutput, "i18n"))
+		// the default answer is the module chosen from the flags and package.json, like without prompts
+		useESM := cli.Confirm("Use ES modules (instead of CommonJS)?", settings.JSModule == project.ESModule)
+		settings.JSModule = project.CommonJS
+		if useESM {
+			settings.JSModule = project.ESModule
+		}
+	} else {
+		settings.JSOutput = ""
+	}
+}
+
+func checkSettings(settings project.Settings, locales []string) []error {
+	var errs []error
+	for _, locale := range append([]string{settings.SourceLocale}, locales...) {
+		if !lang.IsValidLocale(locale) {
+			errs = append(errs, fmt.Errorf("%q is not a valid locale", locale))
+		}
+	}
+	if slices.Contains(locales, settings.SourceLocale) {
+		errs = append(errs, fmt.Errorf("the source locale %s is also a target locale", settings.SourceLocale))
+	}
+	if len(settings.Sources) == 0 {
+		errs = append(errs, errors.New("no sources were given"))
+	}
+	for _, pattern := range settings.Sources {
+		if _, err := glob.Compile(pattern); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if settings.Translations == "" {
+		errs = append(errs, errors.New("no translations directory was given"))
+	}
+	if settings.GoPackage != "" && !token.IsIdentifier(settings.GoPackage) {
+		errs = append(errs, fmt.Errorf("%q is not a valid package name", settings.GoPackage))
+	}
+	return errs
 }
 
-func showInitHelp() {
-	cli.ShowUsage(
-		"Elz init is an interactive tool that helps you set up Elizalina inside a project.",
-		"elz init",
-	)
-	showGlobalOptions()
+func initCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "init",
+		Summary:     "Initialise a new project",
+		Description: "Elz init is an interactive tool that helps you set up Elizalina inside a project.",
+		Usage:       []string{"[--yes] [options]"},
+		Details: []string{"A commented " + project.StdConfigFileName + " is written in the working directory, and an " +
+			"empty translation file is created for each locale. Go modules (go.mod) and npm packages (package.json) " +
+			"are detected to suggest the code to generate. The options below give the default answers to the " +
+			"questions, and when the standard input is not a terminal the default answers are used without asking."},
+		Flags: []*cli.Flag{
+			{Name: "yes", Shorthand: "y", Usage: "Do not ask any question and use the default answers."},
+			{Name: "force", Usage: "Overwrite an existing configuration file."},
+			{Name: "source-locale", Value: "locale", Usage: "The locale messages are written in (en by default)."},
+			{
+				Name: "locale", Shorthand: "L", Value: "locale",
+				Usage: "A locale to translate the messages to. Can be repeated or given a list separated by commas.",
+			},
+			{
+				Name: "source", Shorthand: "S", Value: "pattern",
+				Usage: "A file or pattern to extract messages from. Can be repeated.",
+			},
+			{
+				Name: "translations", Value: "dir",
+				Usage: "The directory of the translation files (translations by default).",
+			},
+			{Name: "go-output", Value: "dir", Usage: "Generate a Go package in this directory."},
+			{Name: "go-package", Value: "name", Usage: "The name of the Go package, if not the name of its directory."},
+			{Name: "js-output", Value: "dir", Usage: "Generate JavaScript modules in this directory."},
+			{
+				Name: "js-module", Value: "esm|cjs", Complete: completeValues("esm", "cjs"),
+				Usage: "The kind of JavaScript modules to generate.",
+			},
+		},
+		Run: cmdInit,
+	}
 }
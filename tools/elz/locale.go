@@ -1,12 +1,278 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
 )
 
-func cmdLocale(arg cli.Args) {
-	cli.Fatal("Command \"locale\" not implemented", cli.InternalError)
+// A locale listed by elz locale list, as written with --output=json.
+type localeRecord struct {
+	Locale string `json:"locale"`
+	Source bool   `json:"source"`
+	// the number of messages of the source locale, and how many of them are translated in this locale
+	Translated int `json:"translated"`
+	Total      int `json:"total"`
+}
+
+func cmdLocaleList(args cli.Args) {
+	args.Done()
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	source := sourceLocale(cfg)
+
+	locales := catalog.Locales()
+	if !slices.Contains(locales, source) {
+		locales = append(locales, source)
+		slices.Sort(locales)
+	}
+	width := 0
+	for _, locale := range locales {
+		width = max(width, len(locale))
+	}
+
+	for _, locale := range locales {
+		translated, total := localeCompletion(catalog, source, locale)
+		percentage := 100
+		if total > 0 {
+			percentage = translated * 100 / total
+		}
+		cli.Emit("locale", localeRecord{
+			Locale: locale, Source: locale == source, Translated: translated, Total: total,
+		})
+		line := fmt.Sprintf("%-*s %3d%%  %d/%d", width, locale, percentage, translated, total)
+		if locale == source {
+			line += " (source)"
+		}
+		cli.Show(line)
+	}
+}
+
+// Count the messages of the source locale that are translated in [locale].
+func localeCompletion(catalog *lang.Catalog, source string, locale string) (translated int, total int) {
+	for _, sourceFile := range catalog.Files {
+		if sourceFile.Locale != source {
+			continue
+		}
+		file := catalog.Get(sourceFile.Prefix, locale)
+		for _, msg := range sourceFile.Messages {
+			total++
+			if file == nil {
+				continue
+			}
+			if translation := file.Lookup(msg.Key); translation != nil && !translation.IsEmpty() {
+				translated++
+			}
+		}
+	}
+	return translated, total
+}
+
+func cmdLocaleAdd(args cli.Args) {
+	from, err := args.StringFlag("from", "", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	locale, err := args.Positional("locale")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	if !lang.IsValidLocale(locale) {
+		cli.Fatal(fmt.Sprintf("%q is not a valid locale", locale), cli.UserError)
+	}
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	opts := formatOptions(cfg)
+	if slices.Contains(catalog.Locales(), locale) {
+		cli.Fatal("locale "+locale+" already exists", cli.UserError)
+	}
+
+	var files []*lang.File
+	if from != "" {
+		// translations are copied from an existing locale
+		for _, original := range catalog.Files {
+			if original.Locale == from {
+				file := *original
+				file.Path = filepath.Join(catalog.Dir, lang.FileName(original.Prefix, locale))
+				file.Locale = locale
+				files = append(files, &file)
+			}
+		}
+		if len(files) == 0 {
+			cli.Fatal("locale "+from+" does not exist", cli.UserError)
+		}
+	} else {
+		// the messages of the source locale are added without translations
+		source := sourceLocale(cfg)
+		for _, sourceFile := range catalog.Files {
+			if sourceFile.Locale == source {
+				file := &lang.File{
+					Path:   filepath.Join(catalog.Dir, lang.FileName(sourceFile.Prefix, locale)),
+					Prefix: sourceFile.Prefix,
+					Locale: locale,
+				}
+				for _, msg := range sourceFile.Messages {
+					file.Add(msg.Key)
+				}
+				files = append(files, file)
+			}
+		}
+		if len(files) == 0 {
+			files = append(files, &lang.File{
+				Path:   filepath.Join(catalog.Dir, lang.FileName(project.NoPrefix, locale)),
+				Prefix: project.NoPrefix,
+				Locale: locale,
+			})
+		}
+	}
+
+	failed := false
+	for _, file := range files {
+		if err := saveFile(file, opts); err != nil {
+			cli.Error("could not write "+displayPath(file.Path), err)
+			failed = true
+		} else {
+			reportFile("created", file.Path)
+		}
+	}
+	if failed {
+		cli.Fatal("locale "+locale+" was partially added", cli.UserError)
+	}
+}
+
+func cmdLocaleRemove(args cli.Args) {
+	locales, err := args.Positionals("locale", 1, -1)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	source := sourceLocale(cfg)
+	for _, locale := range locales {
+		if locale == source {
+			cli.Fatal("cannot remove the source locale ("+source+")", cli.UserError)
+		}
+		if !slices.Contains(catalog.Locales(), locale) {
+			cli.Fatal("locale "+locale+" does not exist", cli.UserError)
+		}
+	}
+
+	failed := false
+	for _, file := range catalog.Files {
+		if !slices.Contains(locales, file.Locale) {
+			continue
+		}
+		if err := os.Remove(file.Path); err != nil {
+			cli.Error("could not remove "+displayPath(file.Path), err)
+			failed = true
+		} else {
+			reportFile("removed", file.Path)
+		}
+	}
+	if failed {
+		cli.Fatal("some files could not be removed", cli.UserError)
+	}
 }
 
-func showLocaleHelp() {
-}
\ No newline at end of file
+func cmdLocaleRename(args cli.Args) {
+	oldLocale, err := args.Positional("locale")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	newLocale, err := args.Positional("new-locale")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	if !lang.IsValidLocale(newLocale) {
+		cli.Fatal(fmt.Sprintf("%q is not a valid locale", newLocale), cli.UserError)
+	}
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	if source := sourceLocale(cfg); oldLocale == source {
+		cli.Fatal("cannot rename the source locale, change sourceLocale in "+project.StdConfigFileName+
+			" and rename the files manually", cli.UserError)
+	}
+	if !slices.Contains(catalog.Locales(), oldLocale) {
+		cli.Fatal("locale "+oldLocale+" does not exist", cli.UserError)
+	}
+	if slices.Contains(catalog.Locales(), newLocale) {
+		cli.Fatal("locale "+newLocale+" already exists", cli.UserError)
+	}
+
+	failed := false
+	for _, file := range catalog.Files {
+		if file.Locale != oldLocale {
+			continue
+		}
+		newPath := filepath.Join(catalog.Dir, lang.FileName(file.Prefix, newLocale))
+		if err := os.Rename(file.Path, newPath); err != nil {
+			cli.Error("could not rename "+displayPath(file.Path), err)
+			failed = true
+		} else {
+			cli.Emit("file", fileRecord{Action: "renamed", Path: displayPath(file.Path), NewPath: displayPath(newPath)})
+			cli.Show("renamed " + displayPath(file.Path) + " to " + displayPath(newPath))
+		}
+	}
+	if failed {
+		cli.Fatal("locale "+oldLocale+" was partially renamed", cli.UserError)
+	}
+}
+
+func localeCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "locale",
+		Aliases:     []string{"locales"},
+		Summary:     "List or update target languages",
+		Description: "Elz locale lists or updates the languages of the project.",
+		Notes:       []string{"Locales are made of letters, digits, hyphens and underscores, like en, fr-CA or zh_Hant."},
+		Default:     "list",
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Aliases: []string{"ls"},
+				Summary: "Show every locale with the number of messages of the source locale that are translated.",
+				Run:     cmdLocaleList,
+			},
+			{
+				Name: "add",
+				Summary: "Create the translation files of a new locale, with the messages of the source locale left " +
+					"untranslated.",
+				Flags: []*cli.Flag{{
+					Name: "from", Value: "locale", Complete: completeLocales,
+					Usage: "Copy the translations of an existing locale instead of starting from scratch.",
+				}},
+				Args: []*cli.Arg{{Name: "locale"}},
+				Run:  cmdLocaleAdd,
+			},
+			{
+				Name:    "remove",
+				Aliases: []string{"rm"},
+				Summary: "Delete the translation files of one or more locales. The source locale (sourceLocale in " +
+					project.StdConfigFileName + ") cannot be removed.",
+				Args: []*cli.Arg{{Name: "locale", Variadic: true, Complete: completeLocales}},
+				Run:  cmdLocaleRemove,
+			},
+			{
+				Name:    "rename",
+				Aliases: []string{"mv"},
+				Summary: "Rename the translation files of a locale.",
+				Args:    []*cli.Arg{{Name: "locale", Complete: completeLocales}, {Name: "new-locale"}},
+				Run:     cmdLocaleRename,
+			},
+		},
+	}
+}
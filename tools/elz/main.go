@@ -1,14 +1,51 @@
 package main
 
 import (
+	"errors"
+	"os"
+	"runtime"
+	"slices"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/project"
 )
 
+// The values of the --config flags, applied to the configuration of the project.
+var configOverrides []project.Override
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "__complete" {
+		// the words are not parsed as arguments, since the last one may be an incomplete flag
+		cmdComplete(os.Args[2:])
+		return
+	}
 	args := cli.ParseArgs()
+	cli.DefaultPrinter().Program = "elz"
+
+	debugging, err := args.BoolFlag("debug", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	color, err := args.BoolFlag("no-color", "", false)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+
+	output, err := args.EnumFlag("output", "", "text", cli.OutputFormats...)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+
+	dp := cli.DefaultPrinter()
+	dp.Debugging = debugging
+	dp.Color = color
+	dp.Output = cli.OutputFormat(slices.Index(cli.OutputFormats, output))
+	cli.Debug("running tool elz version", elzVersion)
+
+	path := commandRegistry().Find(&args)
 
 	if args.HelpFlag() {
-		dispatchCmd(args, true)
+		cli.ShowHelp(path)
 		cli.ShortCircuit()
 	}
 	version, err := args.BoolFlag("version", "v", true)
@@ -20,99 +57,55 @@ func main() {
 		cli.ShortCircuit()
 	}
 
-	debugging, err := args.BoolFlag("debug", "", true)
+	overrides, err := args.StringSliceFlag("config", "")
 	if err != nil {
 		cli.InvalidArgs(err)
 	}
-	color, err := args.BoolFlag("no-color", "", false)
+	for _, arg := range overrides {
+		override, err := project.ParseOverride(arg)
+		if err != nil {
+			cli.InvalidArgs(err)
+		}
+		configOverrides = append(configOverrides, override)
+	}
+
+	recursive, err := args.BoolFlag("recursive", "r", true)
 	if err != nil {
 		cli.InvalidArgs(err)
 	}
-
-	dp := cli.DefaultPrinter()
-	dp.Debugging = debugging
-	dp.Color = color
-	dp.Program = "elz"
-	cli.Debug("running tool elz version", elzVersion)
-
-	dispatchCmd(args, false)
-}
-
-func dispatchCmd(args cli.Args, justShowHelp bool) {
-	command := args.Command()
-	switch command {
-	case "init":
-		if justShowHelp {
-			showInitHelp()
-		} else {
-			cmdInit(args)
-		}
-	case "locale", "locales":
-		if justShowHelp {
-			showLocaleHelp()
-		} else {
-			cmdLocale(args)
-		}
-	case "prefix", "prefixes":
-		if justShowHelp {
-			showPrefixHelp()
-		} else {
-			cmdPrefix(args)
-		}
-	case "message", "messages", "msg":
-		if justShowHelp {
-			showMessageHelp()
-		} else {
-			cmdMessage(args)
-		}
-	case "update", "u":
-		if justShowHelp {
-			showUpdateHelp()
-		} else {
-			cmdUpdate(args)
-		}
-	case "release", "r":
-		if justShowHelp {
-			showReleaseHelp()
-		} else {
-			cmdRelease(args)
-		}
-	case "format", "fmt":
-		if justShowHelp {
-			showFormatHelp()
-		} else {
-			cmdFormat(args)
+	if projectDir, err = args.StringFlag("project", "", ""); err != nil {
+		cli.InvalidArgs(err)
+	}
+	jobs, err := args.IntFlag("jobs", "j", runtime.NumCPU())
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	if jobs < 1 {
+		cli.InvalidArgs(errors.New("Flag \"-j\" or \"--jobs\" should be at least 1"))
+	}
+	supported, parallel := workspaceMode(path)
+	if recursive && !supported {
+		cli.InvalidArgs(errors.New("Flag \"-r\" or \"--recursive\" cannot be used with '" + cli.CommandPath(path) + "'"))
+	}
+	if recursive && projectDir != "" {
+		cli.InvalidArgs(errors.New("Flags \"-r\" or \"--recursive\" and \"--project\" cannot be used together"))
+	}
+	if supported && projectDir == "" {
+		if dirs := workspaceProjects(recursive); dirs != nil {
+			if !parallel {
+				jobs = 1
+			}
+			runWorkspace(dirs, jobs)
 		}
-	case "":
-		showHelp()
-	default:
-		cli.Fatal("unknown command \""+command+"\"", cli.BadUsage)
 	}
-}
-
-func showHelp() {
-	cli.ShowUsage(
-		"Elz is a tool for managing Elizalina translations.",
-		"elz <command> [arguments]",
-	)
-	cli.Show("\nAvailable commands:")
-	cli.DescribeOption("init   ", "Initialise a new project")
-	cli.DescribeOption("locale ", "List or update target languages")
-	cli.DescribeOption("prefix ", "Place translated messages into separate files")
-	cli.DescribeOption("message", "Update translated messages manually")
-	cli.DescribeOption("update ", "Update translated messages automatically")
-	cli.DescribeOption("release", "Transform translations into source code")
-	cli.DescribeOption("format ", "Format translation files")
-	showGlobalOptions()
-}
 
-func showGlobalOptions() {
-	cli.Show("\nGlobal options:")
-	cli.DescribeOption("-h, --help   ", "Show command usage and exit. Run 'elz --help <command>' to get help for a specific command.")
-	cli.DescribeOption("--no-color   ", "Disable colored output. This option can also be set via the NO_COLOR environment variable.")
-	cli.DescribeOption("-v, --version", "Print the tool version and exit.")
+	cli.Run(path, args)
+	cli.Finish()
 }
 
 func showVersion() {
+	cli.Emit("version", struct {
+		Elz string `json:"elz"`
+	}{elzVersion})
 	cli.Show(elzVersion)
 }
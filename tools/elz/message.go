@@ -1,12 +1,282 @@
 package main
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
 )
 
-func cmdMessage(arg cli.Args) {
-	cli.Fatal("Command \"message\" not implemented", cli.InternalError)
+// Find the prefix and key of a message from its ID. If the first part of the ID is a prefix of the catalog, the
+// message is looked up in the files of that prefix, otherwise in the files without prefix.
+func resolveID(catalog *lang.Catalog, id string) (prefix string, key string) {
+	if first, rest, found := strings.Cut(id, "."); found && slices.Contains(catalog.Prefixes(), first) &&
+		first != project.NoPrefix {
+		return first, rest
+	}
+	return project.NoPrefix, id
+}
+
+// The locales of the catalog, plus the source locale if it has no files yet, with the source locale first.
+func catalogLocales(catalog *lang.Catalog, source string) []string {
+	locales := slices.DeleteFunc(catalog.Locales(), func(locale string) bool { return locale == source })
+	return append([]string{source}, locales...)
+}
+
+// A message shown by elz message get, as written with --output=json.
+type messageRecord struct {
+	Key          string              `json:"key"`
+	Translations []translationRecord `json:"translations"`
+}
+
+// The text of a message in a locale, which is only given when the state is translated. The state can also be
+// untranslated or missing.
+type translationRecord struct {
+	Locale string `json:"locale"`
+	State  string `json:"state"`
+	Text   string `json:"text,omitempty"`
 }
 
-func showMessageHelp() {
-}
\ No newline at end of file
+func cmdMessageGet(args cli.Args) {
+	id, err := args.Positional("key")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	prefix, key := resolveID(catalog, id)
+	locales := catalogLocales(catalog, sourceLocale(cfg))
+
+	width := 0
+	for _, locale := range locales {
+		width = max(width, len(locale))
+	}
+
+	found := false
+	record := messageRecord{Key: id, Translations: make([]translationRecord, len(locales))}
+	lines := make([]string, len(locales))
+	for i, locale := range locales {
+		var (
+			text string
+			msg  *lang.Message
+		)
+		if file := catalog.Get(prefix, locale); file != nil {
+			msg = file.Lookup(key)
+		}
+		record.Translations[i].Locale = locale
+		switch {
+		case msg == nil:
+			text = "(missing)"
+			record.Translations[i].State = "missing"
+		case msg.IsEmpty():
+			text = "(not translated)"
+			record.Translations[i].State = "untranslated"
+			found = true
+		default:
+			text = msg.Text.String()
+			record.Translations[i].State = "translated"
+			record.Translations[i].Text = text
+			found = true
+		}
+		lines[i] = fmt.Sprintf("%-*s  %s", width, locale, text)
+	}
+	if !found {
+		cli.Fatal("message "+id+" does not exist", cli.UserError)
+	}
+	cli.Emit("message", record)
+	for _, line := range lines {
+		cli.Show(line)
+	}
+}
+
+func cmdMessageSet(args cli.Args) {
+	locale, err := args.StringFlag("locale", "L", "")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	id, err := args.Positional("key")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	textArg, err := args.Positional("text")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	source := sourceLocale(cfg)
+	if locale == "" {
+		locale = source
+	}
+	if !slices.Contains(catalogLocales(catalog, source), locale) {
+		cli.Fatal("locale "+locale+" does not exist (add it with elz locale add)", cli.UserError)
+	}
+	prefix, key := resolveID(catalog, id)
+	if !lang.IsValidKey(key) {
+		cli.Fatal(fmt.Sprintf("%q is not a valid message key", id), cli.UserError)
+	}
+	text, err := lang.ParseText("<text>", textArg)
+	if err != nil {
+		cli.Fatal("invalid message text", cli.UserError, flattenErrors(err)...)
+	}
+
+	file := getOrCreateFile(catalog, prefix, locale)
+	file.Add(key).Text = text
+	saveMessageFiles(cfg, []*lang.File{file})
+}
+
+func cmdMessageRemove(args cli.Args) {
+	ids, err := args.Positionals("key", 1, -1)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	var changed []*lang.File
+	for _, id := range ids {
+		prefix, key := resolveID(catalog, id)
+		found := false
+		for _, file := range catalog.Files {
+			if file.Prefix == prefix && file.Remove(key) {
+				found = true
+				if !slices.Contains(changed, file) {
+					changed = append(changed, file)
+				}
+			}
+		}
+		if !found {
+			cli.Fatal("message "+id+" does not exist", cli.UserError)
+		}
+	}
+	saveMessageFiles(cfg, changed)
+}
+
+func cmdMessageMove(args cli.Args) {
+	oldID, err := args.Positional("key")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	newID, err := args.Positional("new-key")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	prefix, oldKey := resolveID(catalog, oldID)
+	newPrefix, newKey := resolveID(catalog, newID)
+	if newPrefix != prefix {
+		cli.Fatal("messages cannot be moved to another prefix, use elz prefix split or merge instead", cli.UserError)
+	}
+	if !lang.IsValidKey(newKey) {
+		cli.Fatal(fmt.Sprintf("%q is not a valid message key", newID), cli.UserError)
+	}
+
+	var (
+		changed   []*lang.File
+		conflicts []error
+	)
+	for _, file := range catalog.Files {
+		if file.Prefix != prefix {
+			continue
+		}
+		if existing := file.Lookup(newKey); existing != nil {
+			conflicts = append(conflicts, fmt.Errorf("%s:%s: %s is already defined", displayPath(file.Path),
+				existing.Pos, newID))
+		} else if msg := file.Lookup(oldKey); msg != nil {
+			msg.Key = newKey
+			changed = append(changed, file)
+		}
+	}
+	if len(conflicts) > 0 {
+		cli.Fatal("cannot rename "+oldID, cli.UserError, conflicts...)
+	}
+	if len(changed) == 0 {
+		cli.Fatal("message "+oldID+" does not exist", cli.UserError)
+	}
+
+	var edits []keyEdit
+	for _, call := range scanSources(cfg)[prefix] {
+		if call.Key == oldKey {
+			edits = append(edits, keyEdit{call: call, key: newKey})
+		}
+	}
+	saveMessageFiles(cfg, changed)
+	rewritten, err := rewriteCalls(edits)
+	if err != nil {
+		cli.Fatal("could not update the sources", cli.UserError, err)
+	}
+	for _, path := range rewritten {
+		reportFile("updated", path)
+	}
+}
+
+// Write the translation files changed by a message subcommand, formatted with the rules of the project.
+func saveMessageFiles(cfg project.Config, files []*lang.File) {
+	opts := formatOptions(cfg)
+	order := sourceOrder(cfg, opts)
+	for _, file := range files {
+		fileOpts := opts
+		fileOpts.SourceOrder = order[file.Prefix]
+		if err := saveFile(file, fileOpts); err != nil {
+			cli.Fatal("could not write "+displayPath(file.Path), cli.UserError, err)
+		}
+		reportFile("updated", file.Path)
+	}
+}
+
+func messageCommand() *cli.Command {
+	keyArg := &cli.Arg{Name: "key", Complete: completeKeys}
+	return &cli.Command{
+		Name:        "message",
+		Aliases:     []string{"messages", "msg"},
+		Summary:     "Update translated messages manually",
+		Description: "Elz message reads or updates translated messages manually.",
+		Details: []string{"Keys of messages with a prefix are written <prefix>.<key>. The files are written according " +
+			"to the format section of " + project.StdConfigFileName + "."},
+		Subcommands: []*cli.Command{
+			{
+				Name:    "get",
+				Summary: "Show the text of a message in every locale.",
+				Args:    []*cli.Arg{keyArg},
+				Run:     cmdMessageGet,
+			},
+			{
+				Name: "set",
+				Summary: "Change the text of a message in one locale, adding the message if needed. The text uses the " +
+					"syntax of translation files.",
+				Flags: []*cli.Flag{{
+					Name: "locale", Shorthand: "L", Value: "locale", Complete: completeLocales,
+					Usage: "The locale to change. Defaults to the source locale.",
+				}},
+				Args: []*cli.Arg{keyArg, {Name: "text"}},
+				Run:  cmdMessageSet,
+			},
+			{
+				Name:    "rm",
+				Aliases: []string{"remove"},
+				Summary: "Remove messages from every locale.",
+				Args:    []*cli.Arg{{Name: "key", Variadic: true, Complete: completeKeys}},
+				Run:     cmdMessageRemove,
+			},
+			{
+				Name:    "mv",
+				Aliases: []string{"rename"},
+				Summary: "Change the key of a message in every locale, and in the calls to the translate function in " +
+					"the sources.",
+				Args: []*cli.Arg{keyArg, {Name: "new-key"}},
+				Run:  cmdMessageMove,
+			},
+		},
+	}
+}
@@ -1,12 +1,299 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/extract"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
 )
 
-func cmdPrefix(arg cli.Args) {
-	cli.Fatal("Command \"prefix\" not implemented", cli.InternalError)
+// Read the sources section of the configuration.
+func configSources(cfg project.Config) map[string][]string {
+	sources, err := cfg.Sources()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	return sources
+}
+
+// A prefix listed by elz prefix list, as written with --output=json. Messages without prefix have the prefix "$".
+type prefixRecord struct {
+	Prefix   string   `json:"prefix"`
+	Messages int      `json:"messages"`
+	Sources  []string `json:"sources"`
+}
+
+func cmdPrefixList(args cli.Args) {
+	args.Done()
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	sources := configSources(cfg)
+	source := sourceLocale(cfg)
+
+	prefixes := catalog.Prefixes()
+	for prefix := range sources {
+		if !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	slices.Sort(prefixes)
+
+	names := make([]string, len(prefixes))
+	width := 0
+	for i, prefix := range prefixes {
+		names[i] = prefix
+		if prefix == project.NoPrefix {
+			names[i] += " (no prefix)"
+		}
+		width = max(width, len(names[i]))
+	}
+
+	for i, prefix := range prefixes {
+		count := 0
+		if file := catalog.Get(prefix, source); file != nil {
+			count = len(file.Messages)
+		}
+		cli.Emit("prefix", prefixRecord{Prefix: prefix, Messages: count, Sources: append([]string{}, sources[prefix]...)})
+		line := fmt.Sprintf("%-*s %4d message(s)", width, names[i], count)
+		if patterns := sources[prefix]; len(patterns) > 0 {
+			line += "  from " + strings.Join(patterns, ", ")
+		}
+		cli.Show(line)
+	}
+}
+
+// Read the prefix given as an argument to split or merge.
+func prefixArg(args cli.Args) string {
+	prefix, err := args.Positional("prefix")
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+	if prefix == project.NoPrefix || strings.Contains(prefix, ".") || !lang.IsValidKey(prefix) {
+		cli.Fatal(fmt.Sprintf("%q is not a valid prefix", prefix), cli.UserError)
+	}
+	return prefix
+}
+
+// Move the messages of [from] to [to], changing their keys with [rename]. Messages for which [rename] returns false
+// are kept. Return the number of messages moved, or the keys that are already present in [to].
+func moveMessages(from *lang.File, to *lang.File, rename func(key string) (string, bool)) (int, []error) {
+	var (
+		moved     int
+		conflicts []error
+	)
+	for _, msg := range slices.Clone(from.Messages) {
+		key, ok := rename(msg.Key)
+		if !ok {
+			continue
+		}
+		if to.Lookup(key) != nil {
+			conflicts = append(conflicts, fmt.Errorf("%s:%s: %s is already defined in %s", displayPath(from.Path),
+				msg.Pos, msg.Key, displayPath(to.Path)))
+			continue
+		}
+		from.Remove(msg.Key)
+		msg.Key = key
+		to.Messages = append(to.Messages, msg)
+		moved++
+	}
+	return moved, conflicts
+}
+
+// Return the file of the catalog for [prefix] and [locale], creating it if needed.
+func getOrCreateFile(catalog *lang.Catalog, prefix string, locale string) *lang.File {
+	if file := catalog.Get(prefix, locale); file != nil {
+		return file
+	}
+	file := &lang.File{Path: filepath.Join(catalog.Dir, lang.FileName(prefix, locale)), Prefix: prefix, Locale: locale}
+	catalog.Files = append(catalog.Files, file)
+	return file
+}
+
+func cmdPrefixSplit(args cli.Args) {
+	prefix := prefixArg(args)
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	opts := formatOptions(cfg)
+	sources := configSources(cfg)
+	calls := scanSources(cfg)
+
+	var (
+		changed   []*lang.File
+		conflicts []error
+		total     int
+	)
+	for _, file := range slices.Clone(catalog.Files) {
+		if file.Prefix != project.NoPrefix {
+			continue
+		}
+		target := getOrCreateFile(catalog, prefix, file.Locale)
+		moved, failed := moveMessages(file, target, func(key string) (string, bool) {
+			return strings.CutPrefix(key, prefix+".")
+		})
+		conflicts = append(conflicts, failed...)
+		if moved > 0 {
+			changed = append(changed, file, target)
+			total += moved
+		}
+	}
+	if len(conflicts) > 0 {
+		cli.Fatal("some messages cannot be moved", cli.UserError, conflicts...)
+	}
+	if total == 0 {
+		cli.Fatal("no message starts with \""+prefix+".\"", cli.UserError)
+	}
+
+	// the source files using messages of the prefix are moved to the prefix, so they cannot use other messages
+	only, mixed := extract.SplitPaths(calls[project.NoPrefix], prefix+".")
+	if len(mixed) > 0 {
+		details := make([]error, len(mixed))
+		for i, path := range mixed {
+			details[i] = errors.New(displayPath(path))
+		}
+		cli.Fatal("these files use messages of "+prefix+" along with other messages, separate them before "+
+			"splitting", cli.UserError, details...)
+	}
+	var edits []keyEdit
+	for _, call := range calls[project.NoPrefix] {
+		if slices.Contains(only, call.Path) {
+			edits = append(edits, keyEdit{call: call, key: strings.TrimPrefix(call.Key, prefix+".")})
+		}
+	}
+	for _, path := range only {
+		sources[project.NoPrefix] = append(sources[project.NoPrefix], "!"+configPath(cfg, path))
+		sources[prefix] = append(sources[prefix], configPath(cfg, path))
+	}
+
+	saveChanges(cfg, changed, opts, edits, sources)
+}
+
+func cmdPrefixMerge(args cli.Args) {
+	prefix := prefixArg(args)
+
+	cfg := loadProject()
+	catalog := loadCatalog(cfg)
+	opts := formatOptions(cfg)
+	sources := configSources(cfg)
+	calls := scanSources(cfg)
+
+	var (
+		changed   []*lang.File
+		removed   []*lang.File
+		conflicts []error
+	)
+	for _, file := range slices.Clone(catalog.Files) {
+		if file.Prefix != prefix {
+			continue
+		}
+		target := getOrCreateFile(catalog, project.NoPrefix, file.Locale)
+		_, failed := moveMessages(file, target, func(key string) (string, bool) {
+			return prefix + "." + key, true
+		})
+		conflicts = append(conflicts, failed...)
+		changed = append(changed, target)
+		removed = append(removed, file)
+	}
+	if len(conflicts) > 0 {
+		cli.Fatal("some messages cannot be moved", cli.UserError, conflicts...)
+	}
+	if len(removed) == 0 && sources[prefix] == nil {
+		cli.Fatal("prefix "+prefix+" does not exist", cli.UserError)
+	}
+
+	var edits []keyEdit
+	for _, call := range calls[prefix] {
+		edits = append(edits, keyEdit{call: call, key: prefix + "." + call.Key})
+	}
+	if patterns, ok := sources[prefix]; ok {
+		// the exclusions added when splitting are not needed anymore
+		sources[project.NoPrefix] = slices.DeleteFunc(sources[project.NoPrefix], func(pattern string) bool {
+			return slices.Contains(patterns, strings.TrimPrefix(pattern, "!"))
+		})
+		sources[project.NoPrefix] = append(sources[project.NoPrefix], patterns...)
+		delete(sources, prefix)
+	}
+
+	saveChanges(cfg, changed, opts, edits, sources)
+	for _, file := range removed {
+		if err := os.Remove(file.Path); err != nil {
+			cli.Fatal("could not remove "+displayPath(file.Path), cli.UserError, err)
+		}
+		reportFile("removed", file.Path)
+	}
+}
+
+// Write the translation files, source files and configuration changed by split or merge.
+func saveChanges(cfg project.Config, files []*lang.File, opts lang.FormatOptions, edits []keyEdit,
+	sources map[string][]string) {
+	var saved []string
+	for _, file := range files {
+		if slices.Contains(saved, file.Path) {
+			continue
+		}
+		if err := saveFile(file, opts); err != nil {
+			cli.Fatal("could not write "+displayPath(file.Path), cli.UserError, err)
+		}
+		saved = append(saved, file.Path)
+		reportFile("updated", file.Path)
+	}
+
+	rewritten, err := rewriteCalls(edits)
+	if err != nil {
+		cli.Fatal("could not update the sources", cli.UserError, err)
+	}
+	for _, path := range rewritten {
+		reportFile("updated", path)
+	}
+
+	configFile := filepath.Join(cfg.Dir(), project.StdConfigFileName)
+	if err := project.WriteSources(configFile, sources); err != nil {
+		cli.Fatal("could not update "+displayPath(configFile), cli.UserError, err)
+	}
+	reportFile("updated", configFile)
 }
 
-func showPrefixHelp() {
-}
\ No newline at end of file
+func prefixCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "prefix",
+		Aliases:     []string{"prefixes"},
+		Summary:     "Place translated messages into separate files",
+		Description: "Elz prefix places translated messages into separate files.",
+		Details: []string{"Messages with a prefix are stored in files named <prefix>.<locale>" + lang.Extension +
+			", and the sources listed under that prefix in " + project.StdConfigFileName +
+			" use keys relative to it."},
+		Default: "list",
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Aliases: []string{"ls"},
+				Summary: "Show every prefix with its number of messages and its sources.",
+				Run:     cmdPrefixList,
+			},
+			{
+				Name: "split",
+				Summary: "Move the messages whose key starts with \"<prefix>.\" into the files of the prefix. Source " +
+					"files using these messages are moved to the prefix in the sources section, and their keys are " +
+					"shortened. They cannot use other messages.",
+				Args: []*cli.Arg{{Name: "prefix"}},
+				Run:  cmdPrefixSplit,
+			},
+			{
+				Name: "merge",
+				Summary: "Move the messages of a prefix back into the files without prefix, and its sources with " +
+					"them.",
+				Args: []*cli.Arg{{Name: "prefix", Complete: completePrefixes}},
+				Run:  cmdPrefixMerge,
+			},
+		},
+	}
+}
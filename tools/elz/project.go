@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/extract"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+// Find and load the configuration of the project containing the working directory.
+func loadProject() project.Config {
+	var (
+		path  string
+		found bool
+	)
+	if projectDir != "" {
+		path = filepath.Join(projectDir, project.StdConfigFileName)
+		if _, err := os.Stat(path); err != nil {
+			cli.Fatal("could not find "+project.StdConfigFileName+" in "+projectDir, cli.UserError)
+		}
+	} else if path, found = project.FindConfigFile("."); !found {
+		cli.Fatal("could not find "+project.StdConfigFileName+" in this directory or any of its parents", cli.UserError)
+	}
+	cli.Debug("using config file", path)
+
+	path = displayPath(path)
+	cfg, err := project.LoadConfigFile(path, configOverrides...)
+	if err != nil {
+		reportAll(cli.LevelError, err)
+		cli.Abort(cli.UserError)
+	}
+	for _, key := range cfg.Unused() {
+		unknown := cli.Diagnostic{
+			Level: cli.LevelWarning, Code: codeUnknownKey, Message: "unknown key " + key.Path,
+			Span: &cli.Span{
+				File: key.File, Line: key.Line, Column: key.Column,
+				// underline the name of the key without the colon after it
+				Length: utf8.RuneCountInString(key.Path[strings.LastIndex(key.Path, ".")+1:]),
+			},
+		}
+		if key.Suggestion != "" {
+			unknown.Hints = []string{"did you mean " + key.Suggestion + "?"}
+		}
+		cli.Report(unknown)
+	}
+	return cfg
+}
+
+// Return the path of the translations directory of the project, relative to the working directory if possible.
+func translationsDir(cfg project.Config) string {
+	dir, err := cfg.Translations()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	if dir == "" {
+		cli.Fatal("the translations directory is not set in "+project.StdConfigFileName, cli.UserError)
+	}
+	return displayPath(filepath.Join(cfg.Dir(), dir))
+}
+
+// Load all the translation files of the project, and fail if some of them are invalid.
+func loadCatalog(cfg project.Config) *lang.Catalog {
+	catalog, err := lang.LoadDir(translationsDir(cfg))
+	if err != nil {
+		reportAll(cli.LevelError, err)
+		cli.Abort(cli.UserError)
+	}
+	return catalog
+}
+
+// Return the source locale of the project, which must be set.
+func sourceLocale(cfg project.Config) string {
+	locale, err := cfg.SourceLocale()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	if locale == "" {
+		cli.Fatal("the source locale is not set in "+project.StdConfigFileName, cli.UserError)
+	}
+	return locale
+}
+
+// Read the format rules of the project.
+func formatOptions(cfg project.Config) lang.FormatOptions {
+	opts, err := lang.FormatOptionsFrom(cfg.Format())
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	return opts
+}
+
+// Find the calls to the translate functions in the sources of each prefix.
+func scanSources(cfg project.Config) map[string][]extract.Call {
+	files, err := project.SourceFiles(cfg)
+	if err != nil {
+		cli.Fatal("could not list source files", cli.UserError, err)
+	}
+	var opts extract.Options
+	if opts.GoTranslateFn, err = cfg.Go().TranslateFn(); err != nil {
+		abortInvalidConfig(err)
+	}
+	if opts.JSTranslateFn, err = cfg.JS().TranslateFn(); err != nil {
+		abortInvalidConfig(err)
+	}
+
+	var (
+		calls = make(map[string][]extract.Call, len(files))
+		errs  []error
+	)
+	for prefix, paths := range files {
+		for i := range paths {
+			paths[i] = displayPath(paths[i])
+		}
+		found, failed := extract.Files(paths, opts)
+		errs = append(errs, failed...)
+		for _, call := range found {
+			invalid := cli.Diagnostic{
+				Level: cli.LevelWarning, Code: codeInvalidCall,
+				Span: &cli.Span{File: call.Path, Line: call.Line, Column: call.Column},
+			}
+			if call.Dynamic {
+				invalid.Message = "the key is not a string literal, the message cannot be extracted"
+				cli.Report(invalid)
+			} else if !lang.IsValidKey(call.Key) {
+				invalid.Message = fmt.Sprintf("%q is not a valid message key, the message cannot be extracted", call.Key)
+				cli.Report(invalid)
+			} else {
+				calls[prefix] = append(calls[prefix], call)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		cli.Fatal("could not read some source files", cli.UserError, errs...)
+	}
+	return calls
+}
+
+// Return the keys used in the sources of each prefix if messages are sorted in source order, or <nil> otherwise.
+func sourceOrder(cfg project.Config, opts lang.FormatOptions) map[string][]string {
+	if opts.SortMessages != project.Source {
+		return nil
+	}
+	keys := make(map[string][]string)
+	for prefix, calls := range scanSources(cfg) {
+		keys[prefix] = extract.Keys(calls)
+	}
+	return keys
+}
+
+// Write a translation file in the format of the project.
+func saveFile(file *lang.File, opts lang.FormatOptions) error {
+	return os.WriteFile(file.Path, lang.Format(file, opts), 0666)
+}
+
+// The new key of a call to the translate function.
+type keyEdit struct {
+	call extract.Call
+	key  string
+}
+
+// Replace the keys of calls in the source files, keeping their quotes. Return the paths of the files that were changed.
+func rewriteCalls(edits []keyEdit) ([]string, error) {
+	byPath := make(map[string][]keyEdit)
+	var paths []string
+	for _, edit := range edits {
+		if _, ok := byPath[edit.call.Path]; !ok {
+			paths = append(paths, edit.call.Path)
+		}
+		byPath[edit.call.Path] = append(byPath[edit.call.Path], edit)
+	}
+
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// edits are applied from the end of the file so that the offsets of the others stay valid
+		fileEdits := byPath[path]
+		slices.SortFunc(fileEdits, func(a, b keyEdit) int { return b.call.Start - a.call.Start })
+		for _, edit := range fileEdits {
+			start, end := edit.call.Start, edit.call.End
+			literal := string(src[start:start+1]) + edit.key + string(src[end-1:end])
+			src = slices.Concat(src[:start], []byte(literal), src[end:])
+		}
+		if err := os.WriteFile(path, src, 0666); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// Return the path of a source file relative to the directory of the configuration, as used in the sources section.
+func configPath(cfg project.Config, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	dir, err := filepath.Abs(cfg.Dir())
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Return wether a file with [prefix] and [locale] should be targeted, given the values of the -P and -L flags.
+func matchesFilters(prefix string, locale string, prefixes []string, locales []string) bool {
+	return (len(prefixes) == 0 || slices.Contains(prefixes, prefix)) &&
+		(len(locales) == 0 || slices.Contains(locales, locale))
+}
+
+// Shorten a path to make it relative to the working directory if possible.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil || len(rel) > len(abs) {
+		return path
+	}
+	return rel
+}
+
+// A file changed by a command, as written with --output=json.
+type fileRecord struct {
+	// created, updated, removed or renamed
+	Action  string `json:"action"`
+	Path    string `json:"path"`
+	NewPath string `json:"newPath,omitempty"`
+}
+
+// Report that a file was created, updated or removed.
+func reportFile(action string, path string) {
+	cli.Emit("file", fileRecord{Action: action, Path: displayPath(path)})
+	cli.Show(action + " " + displayPath(path))
+}
@@ -1,12 +1,226 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/project"
+	"github.com/louisdevie/elizalina2/internal/release"
 )
 
-func cmdRelease(arg cli.Args) {
-	cli.Fatal("Command \"release\" not implemented", cli.InternalError)
+// The files written for a target by elz release, as written with --output=json.
+type releaseRecord struct {
+	// go or js
+	Target  string   `json:"target"`
+	Output  string   `json:"output"`
+	Written []string `json:"written"`
+	Removed []string `json:"removed"`
+}
+
+func cmdRelease(args cli.Args) {
+	args.Done()
+
+	cfg := loadProject()
+	bundle := loadBundle(cfg)
+	released := false
+
+	goOutput, err := cfg.Go().Output()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	if goOutput != "" {
+		releaseGo(cfg, bundle, filepath.Join(cfg.Dir(), goOutput))
+		released = true
+	}
+
+	jsOutput, err := cfg.JS().Output()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	if jsOutput != "" {
+		releaseJS(cfg, bundle, filepath.Join(cfg.Dir(), jsOutput))
+		released = true
+	}
+
+	if !released {
+		cli.Fatal("nothing to release, add a \"go\" or \"js\" section to "+project.StdConfigFileName, cli.UserError)
+	}
 }
 
-func showReleaseHelp() {
-}
\ No newline at end of file
+// Load the translations of the project and check that they can be released.
+func loadBundle(cfg project.Config) *release.Bundle {
+	bundle, err := release.NewBundle(loadCatalog(cfg), sourceLocale(cfg))
+	if err != nil {
+		for _, inconsistency := range flattenErrors(err) {
+			cli.Report(cli.Diagnostic{Level: cli.LevelError, Code: codeInconsistent, Message: inconsistency.Error()})
+		}
+		cli.Abort(cli.UserError)
+	}
+	for _, warning := range bundle.Warnings {
+		cli.Report(cli.Diagnostic{Level: cli.LevelWarning, Code: codeNotReleased, Message: warning.Error()})
+	}
+	return bundle
+}
+
+func releaseGo(cfg project.Config, bundle *release.Bundle, output string) {
+	pkg, err := cfg.Go().Package()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	files, err := release.GenerateGo(bundle, release.GoOptions{Package: pkg})
+	if err != nil {
+		cli.Fatal("could not generate Go code", cli.UserError, flattenErrors(err)...)
+	}
+	written, removed := writeGenerated(output, files)
+	cli.Emit("release", releaseRecord{Target: "go", Output: displayPath(output), Written: written, Removed: removed})
+}
+
+func releaseJS(cfg project.Config, bundle *release.Bundle, output string) {
+	var (
+		opts release.JSOptions
+		err  error
+	)
+	jsConfig := cfg.JS()
+	if opts.Module, err = jsConfig.Module(); err != nil {
+		abortInvalidConfig(err)
+	}
+	if opts.Minify, err = jsConfig.Minify(); err != nil {
+		abortInvalidConfig(err)
+	}
+	if opts.TranslateFn, err = jsConfig.TranslateFn(); err != nil {
+		abortInvalidConfig(err)
+	}
+	files, err := release.GenerateJS(bundle, opts)
+	if err != nil {
+		cli.Fatal("could not generate JavaScript code", cli.UserError, flattenErrors(err)...)
+	}
+	record := releaseRecord{Target: "js", Output: displayPath(output)}
+	record.Written, record.Removed = writeGenerated(output, files)
+
+	entryPoint, err := jsConfig.EntryPoint()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	if entryPoint != "" {
+		entryPoint = filepath.Join(cfg.Dir(), entryPoint)
+		importPath, err := filepath.Rel(filepath.Dir(entryPoint), filepath.Join(output, "index.js"))
+		if err != nil {
+			cli.Fatal("could not locate the output from the entry point", cli.UserError, err)
+		}
+		importPath = filepath.ToSlash(importPath)
+		if !strings.HasPrefix(importPath, ".") {
+			importPath = "./" + importPath
+		}
+		if writeGeneratedFile(entryPoint, release.GenerateJSEntryPoint(opts, importPath)) {
+			record.Written = append(record.Written, displayPath(entryPoint))
+		}
+	}
+	cli.Emit("release", record)
+}
+
+// Write generated files into [dir], and remove previously generated files that are not needed anymore. The paths of
+// the files that changed are returned.
+func writeGenerated(dir string, files map[string][]byte) (written []string, removed []string) {
+	written, removed = []string{}, []string{}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		cli.Fatal("could not create "+displayPath(dir), cli.UserError, err)
+	}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if _, keep := files[filepath.ToSlash(name)]; keep || err != nil {
+			return err
+		}
+		if content, err := os.ReadFile(path); err == nil && isGenerated(content) {
+			cli.Debug("removing", path)
+			if err := os.Remove(path); err != nil {
+				cli.Error("could not remove "+displayPath(path), err)
+			} else {
+				removed = append(removed, displayPath(path))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cli.Fatal("could not list "+displayPath(dir), cli.UserError, err)
+	}
+
+	names := slices.Sorted(maps.Keys(files))
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if writeGeneratedFile(path, files[name]) {
+			written = append(written, displayPath(path))
+		}
+	}
+	return written, removed
+}
+
+// Write a generated file, unless it already has the right content, and return wether it was written. Files that were
+// not generated by elz are never overwritten.
+func writeGeneratedFile(path string, content []byte) bool {
+	previous, err := os.ReadFile(path)
+	if err == nil {
+		if bytes.Equal(previous, content) {
+			return false
+		}
+		if !isGenerated(previous) && !isGeneratedPackageJSON(path, previous) {
+			cli.Fatal("refusing to overwrite "+displayPath(path)+", which was not generated by elz", cli.UserError)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		cli.Fatal("could not create "+displayPath(filepath.Dir(path)), cli.UserError, err)
+	}
+	cli.Debug("writing", path)
+	if err := os.WriteFile(path, content, 0666); err != nil {
+		cli.Fatal("could not write "+displayPath(path), cli.UserError, err)
+	}
+	return true
+}
+
+// Return wether a file was generated by elz, in which case it can be overwritten or removed.
+func isGenerated(content []byte) bool {
+	firstLine, _, _ := strings.Cut(string(content), "\n")
+	return strings.Contains(firstLine, strings.TrimPrefix(release.GeneratedHeader, "// "))
+}
+
+// Return wether a file is a package.json that only sets the module type, which is what is generated for JavaScript
+// (JSON files cannot have a header comment).
+func isGeneratedPackageJSON(path string, content []byte) bool {
+	var fields map[string]any
+	if filepath.Base(path) != "package.json" || json.Unmarshal(content, &fields) != nil {
+		return false
+	}
+	_, hasType := fields["type"]
+	return len(fields) == 1 && hasType
+}
+
+func releaseCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "release",
+		Aliases:     []string{"r"},
+		Summary:     "Transform translations into source code",
+		Description: "Elz release transforms translations into source code that can be embedded in a program.",
+		Details: []string{"Code is generated for each target configured in " + project.StdConfigFileName + ". " +
+			"Messages that are not translated fall back on the source locale (sourceLocale)."},
+		Sections: []cli.Section{{Title: "Targets", Entries: []cli.Entry{
+			{Name: "go", Description: "A Go package in go.output, named after go.package or the output directory. " +
+				"Each message is a method of the Messages interface, and For(locale) returns the implementation of a " +
+				"locale."},
+			{Name: "js", Description: "JavaScript modules with TypeScript declarations in js.output, using ES modules " +
+				"or CommonJS according to js.module. The index module exports the translate function (js.translateFn) " +
+				"and setLocale, and each prefix has its own module. If js.entryPoint is set, a module re-exporting the " +
+				"index is written there. Set js.minify to true to minify the code."},
+		}}},
+		Run: cmdRelease,
+	}
+}
@@ -1,12 +1,183 @@
 package main
 
 import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
 	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/extract"
+	"github.com/louisdevie/elizalina2/internal/lang"
+	"github.com/louisdevie/elizalina2/internal/project"
 )
 
-func cmdUpdate(arg cli.Args) {
-	cli.Fatal("Command \"update\" not implemented", cli.InternalError)
+// The changes made to a translation file.
+type fileChanges struct {
+	file    *lang.File
+	added   int
+	removed int
 }
 
-func showUpdateHelp() {
-}
\ No newline at end of file
+func cmdUpdate(args cli.Args) {
+	check, err := args.BoolFlag("check", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	prune, err := args.ScopedBoolFlag("prune", "", true)
+	if err != nil {
+		cli.InvalidArgs(err)
+	}
+	args.Done()
+
+	cfg := loadProject()
+	calls := scanSources(cfg)
+	catalog := loadCatalog(cfg)
+	opts := formatOptions(cfg)
+
+	locales := catalog.Locales()
+	if source := sourceLocale(cfg); !slices.Contains(locales, source) {
+		locales = append(locales, source)
+	}
+
+	var changes []*fileChanges
+	changesTo := func(file *lang.File) *fileChanges {
+		for _, c := range changes {
+			if c.file == file {
+				return c
+			}
+		}
+		c := &fileChanges{file: file}
+		changes = append(changes, c)
+		return c
+	}
+
+	// every configured prefix is updated, even when no call is left in its sources
+	sources, err := cfg.Sources()
+	if err != nil {
+		abortInvalidConfig(err)
+	}
+	prefixes := make([]string, 0, len(sources))
+	for prefix := range sources {
+		prefixes = append(prefixes, prefix)
+	}
+	slices.Sort(prefixes)
+
+	for _, prefix := range prefixes {
+		keys := extract.Keys(calls[prefix])
+
+		for _, locale := range locales {
+			file := catalog.Get(prefix, locale)
+			for _, key := range keys {
+				if file == nil {
+					file = &lang.File{
+						Path:   filepath.Join(catalog.Dir, lang.FileName(prefix, locale)),
+						Prefix: prefix,
+						Locale: locale,
+					}
+					catalog.Files = append(catalog.Files, file)
+				}
+				if file.Lookup(key) == nil {
+					file.Add(key)
+					changesTo(file).added++
+				}
+			}
+		}
+
+		if source := catalog.Get(prefix, sourceLocale(cfg)); source != nil {
+			for _, msg := range slices.Clone(source.Messages) {
+				if slices.Contains(keys, msg.Key) {
+					continue
+				}
+				if !prune[""] && !prune[prefix] {
+					cli.Report(cli.Diagnostic{
+						Level: cli.LevelWarning, Code: codeUnusedMessage,
+						Message: "message " + msg.Key + " is not used in the sources anymore",
+						Span:    &cli.Span{File: displayPath(source.Path), Line: msg.Pos.Line, Column: msg.Pos.Column},
+						Hints:   []string{"run elz update --prune to remove it from every locale"},
+					})
+				} else {
+					for _, locale := range locales {
+						if file := catalog.Get(prefix, locale); file != nil && file.Remove(msg.Key) {
+							changesTo(file).removed++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if check {
+		for _, c := range changes {
+			reportChanges(c, false)
+			cli.Report(cli.Diagnostic{
+				Level: cli.LevelError, Code: codeOutdated,
+				Message: fmt.Sprintf("%s is not up to date: %d to add, %d to remove",
+					displayPath(c.file.Path), c.added, c.removed),
+				Span:  &cli.Span{File: displayPath(c.file.Path)},
+				Hints: []string{"run elz update to update it"},
+			})
+		}
+		return
+	}
+
+	for _, c := range changes {
+		fileOpts := opts
+		fileOpts.SourceOrder = extract.Keys(calls[c.file.Prefix])
+		if err := saveFile(c.file, fileOpts); err != nil {
+			cli.Error("could not write "+displayPath(c.file.Path), err)
+		} else {
+			reportChanges(c, true)
+		}
+	}
+}
+
+// The messages added to or removed from a file by elz update, as written with --output=json.
+type changesRecord struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	// false when the file needs these changes but was not written, with --check
+	Written bool `json:"written"`
+}
+
+// Report the changes made to a file, or the changes it needs if [written] is false.
+func reportChanges(c *fileChanges, written bool) {
+	cli.Emit("changes", changesRecord{
+		Path: displayPath(c.file.Path), Added: c.added, Removed: c.removed, Written: written,
+	})
+	if written {
+		cli.Show(describeChanges(c))
+	}
+}
+
+func describeChanges(c *fileChanges) string {
+	return fmt.Sprintf("%s: %d added, %d removed", displayPath(c.file.Path), c.added, c.removed)
+}
+
+func updateCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "update",
+		Aliases:     []string{"u"},
+		Summary:     "Update translated messages automatically",
+		Description: "Elz update adds the messages used in the sources to the translation files.",
+		Usage:       []string{"[--check] [--prune[:<prefix>]]"},
+		Details: []string{"The files listed in the \"sources\" section of " + project.StdConfigFileName + " are " +
+			"searched for calls to the translate function whose first argument is a string literal. In Go files, the " +
+			"function is named after go.translateFn (T by default), and in JavaScript and TypeScript files after " +
+			"js.translateFn (__ by default), in which case template literals without substitutions are also accepted " +
+			"as keys. New keys are added as empty messages to every locale, in the files of the prefix the source " +
+			"belongs to. Messages that are not used anymore are reported."},
+		Flags: []*cli.Flag{
+			{
+				Name:  "check",
+				Usage: "Assert that the translation files are up to date, or fail with a summary of the files to update.",
+			},
+			{
+				Name: "prune", Scope: "prefix", Scopes: completePrefixes,
+				Usage: "Remove the messages that are not used anymore from every locale, or only from the files of a " +
+					"prefix when one is given (can be specified multiple times).",
+			},
+		},
+		Run: cmdUpdate,
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+
+	"github.com/louisdevie/elizalina2/internal/cli"
+	"github.com/louisdevie/elizalina2/internal/project"
+)
+
+// The value of the --project flag.
+var projectDir string
+
+// Return wether the command of [path] works on a single project and can be run across a workspace, and wether it can
+// run in several projects at the same time. Commands that may rewrite source files run one project at a time, since
+// projects can share sources.
+func workspaceMode(path []*cli.Command) (supported bool, parallel bool) {
+	if len(path) < 2 {
+		return false, false
+	}
+	switch path[1].Name {
+	case "update", "release", "format":
+		return true, true
+	case "locale", "prefix", "message":
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// Return the directories of the projects the command should run in, or <nil> if it should only run in the project
+// containing the working directory.
+func workspaceProjects(recursive bool) []string {
+	var configs []string
+	if recursive {
+		found, err := project.FindProjects(".", nil)
+		if err != nil {
+			cli.Fatal("could not search for projects", cli.UserError, err)
+		}
+		if len(found) == 0 {
+			cli.Fatal("could not find "+project.StdConfigFileName+" in this directory or below", cli.UserError)
+		}
+		configs = found
+	} else {
+		path, found := project.FindWorkspaceFile(".")
+		if !found {
+			return nil
+		}
+		cli.Debug("using workspace file", path)
+		path = displayPath(path)
+		workspace, err := project.LoadWorkspaceFile(path)
+		if err != nil {
+			cli.Fatal("could not read "+path, cli.UserError, err)
+		}
+		if len(workspace.Projects) == 0 {
+			cli.Fatal("the workspace "+path+" contains no projects", cli.UserError)
+		}
+		configs = workspace.Projects
+	}
+
+	dirs := make([]string, len(configs))
+	for i, config := range configs {
+		dirs[i] = displayPath(filepath.Dir(config))
+	}
+	return dirs
+}
+
+// The summary of a command run across a workspace, as written with --output=json. The objects written by each project
+// follow an object of type "project" with its directory and exit code.
+type workspaceRecord struct {
+	Succeeded int             `json:"succeeded"`
+	Projects  []projectRecord `json:"projects"`
+}
+
+type projectRecord struct {
+	Dir      string `json:"dir"`
+	ExitCode int    `json:"exitCode"`
+}
+
+// The result of a command in one project of a workspace.
+type projectRun struct {
+	dir    string
+	output bytes.Buffer
+	code   int
+	err    error
+	done   chan struct{}
+}
+
+// Run the command line in each project by calling this program again with --project, then exit with the highest exit
+// code. At most [workers] projects are processed at the same time, and the output of each project is shown once it is
+// done, in the order of [dirs].
+func runWorkspace(dirs []string, workers int) {
+	executable, err := os.Executable()
+	if err != nil {
+		cli.Fatal("could not find the elz executable", cli.InternalError, err)
+	}
+	// the arguments are passed on as they were written, except for the flags selecting projects
+	var baseArgs []string
+	for _, arg := range os.Args[1:] {
+		if arg != "--recursive" && arg != "-r" {
+			baseArgs = append(baseArgs, arg)
+		}
+	}
+
+	runs := make([]*projectRun, len(dirs))
+	for i, dir := range dirs {
+		runs[i] = &projectRun{dir: dir, done: make(chan struct{})}
+	}
+	slots := make(chan struct{}, workers)
+	go func() {
+		// the runs are started in order, so that they happen in order when there is a single worker
+		for _, run := range runs {
+			slots <- struct{}{}
+			go func() {
+				defer close(run.done)
+				cmd := exec.Command(executable, slices.Concat([]string{"--project", run.dir}, baseArgs)...)
+				cmd.Stdout = &run.output
+				cmd.Stderr = &run.output
+				if cli.DefaultPrinter().Output != cli.TextOutput {
+					// only the objects are buffered, debugging messages are written as they come
+					cmd.Stderr = os.Stderr
+				}
+				run.err = cmd.Run()
+				<-slots
+			}()
+		}
+	}()
+
+	width, succeeded, exitCode := 0, 0, 0
+	for _, dir := range dirs {
+		width = max(width, len(dir))
+	}
+	for i := range dirs {
+		run := runs[i]
+		<-run.done
+		var exitErr *exec.ExitError
+		if errors.As(run.err, &exitErr) {
+			run.code = exitErr.ExitCode()
+		} else if run.err != nil {
+			run.code = int(cli.InternalError)
+		}
+
+		cli.Emit("project", projectRecord{Dir: run.dir, ExitCode: run.code})
+		cli.Show("==> " + run.dir)
+		os.Stdout.Write(run.output.Bytes())
+		if run.err != nil && exitErr == nil {
+			cli.Error("could not run elz in "+run.dir, run.err)
+		}
+		if run.code == 0 {
+			succeeded++
+		}
+		exitCode = max(exitCode, run.code)
+	}
+
+	record := workspaceRecord{Succeeded: succeeded, Projects: make([]projectRecord, len(runs))}
+	for i, run := range runs {
+		record.Projects[i] = projectRecord{Dir: run.dir, ExitCode: run.code}
+	}
+	cli.Emit("workspace", record)
+	cli.Show(fmt.Sprintf("\n%d of %d projects succeeded", succeeded, len(runs)))
+	for _, run := range runs {
+		status := "ok"
+		if run.code != 0 {
+			status = fmt.Sprintf("failed (exit code %d)", run.code)
+		}
+		cli.Show(fmt.Sprintf("   %-*s  %s", width, run.dir, status))
+	}
+	os.Exit(exitCode)
+}